@@ -29,17 +29,86 @@ func startAPI() {
 	r := e.Group("/api")
 
 	r.GET("/categories", controllers.CategoryController.Index)
+	r.POST("/categories", controllers.CategoryController.Create)
 	r.POST("/categories/:id", controllers.CategoryController.Update)
+	r.DELETE("/categories/:id", controllers.CategoryController.Delete)
+	r.POST("/categories/:id/merge", controllers.CategoryController.Merge)
+
+	r.GET("/rules", controllers.CategoryRuleController.Index)
+	r.POST("/rules", controllers.CategoryRuleController.Create)
+	r.POST("/rules/test", controllers.CategoryRuleController.Test)
+	r.POST("/rules/apply", controllers.CategoryRuleController.Apply)
+	r.POST("/rules/:id", controllers.CategoryRuleController.Update)
+	r.DELETE("/rules/:id", controllers.CategoryRuleController.Delete)
 
 	r.GET("/expenditures", controllers.ExpenditureController.Index)
 	r.GET("/expenditures/:id", controllers.ExpenditureController.Show)
 	r.POST("/expenditures/:id", controllers.ExpenditureController.Update)
 	r.DELETE("/expenditures/:id", controllers.ExpenditureController.Delete)
 	r.POST("/expenditures", controllers.ExpenditureController.Create)
+	r.GET("/expenditures/suggest-category", controllers.ExpenditureController.SuggestCategory)
+	r.GET("/expenditures/duplicates", controllers.DuplicateController.Index)
+	r.POST("/expenditures/duplicates/scan", controllers.DuplicateController.Scan)
+	r.POST("/expenditures/duplicates/:id", controllers.DuplicateController.Resolve)
+
+	r.GET("/incomes", controllers.IncomeController.Index)
+	r.GET("/incomes/:id", controllers.IncomeController.Show)
+	r.POST("/incomes/:id", controllers.IncomeController.Update)
+	r.DELETE("/incomes/:id", controllers.IncomeController.Delete)
+	r.POST("/incomes", controllers.IncomeController.Create)
+	r.GET("/incomes/suggest-category", controllers.IncomeController.SuggestCategory)
+
+	r.GET("/accounts", controllers.AccountController.Index)
+	r.GET("/accounts/:id", controllers.AccountController.Show)
+	r.GET("/accounts/:id/balances", controllers.AccountController.Balances)
+	r.POST("/accounts/:id", controllers.AccountController.Update)
+	r.DELETE("/accounts/:id", controllers.AccountController.Delete)
+	r.POST("/accounts", controllers.AccountController.Create)
+
+	r.GET("/transfers", controllers.TransferController.Index)
+	r.DELETE("/transfers/:id", controllers.TransferController.Delete)
+	r.POST("/transfers", controllers.TransferController.Create)
+
+	r.GET("/tags", controllers.TagController.Index)
+	r.POST("/tags", controllers.TagController.Create)
+	r.POST("/tags/:id", controllers.TagController.Update)
+	r.DELETE("/tags/:id", controllers.TagController.Delete)
+
+	r.GET("/recurring", controllers.RecurringController.Index)
+	r.POST("/recurring", controllers.RecurringController.Create)
+	r.POST("/recurring/run", controllers.RecurringController.Run)
+	r.POST("/recurring/:id", controllers.RecurringController.Update)
+	r.DELETE("/recurring/:id", controllers.RecurringController.Delete)
+
+	r.GET("/subscriptions", controllers.SubscriptionController.Index)
+	r.POST("/subscriptions/convert", controllers.SubscriptionController.Convert)
+
+	r.GET("/budgets", controllers.BudgetController.Index)
+	r.POST("/budgets", controllers.BudgetController.Create)
+	r.POST("/budgets/:id", controllers.BudgetController.Update)
+	r.DELETE("/budgets/:id", controllers.BudgetController.Delete)
 
 	r.GET("/stats/categories", controllers.CategoryStatsController.Index)
+	r.GET("/stats/budgets", controllers.BudgetStatsController.Index)
+	r.GET("/stats/cashflow", controllers.CashFlowStatsController.Index)
+	r.GET("/stats/tags", controllers.TagStatsController.Index)
+	r.GET("/stats/timeseries", controllers.TimeSeriesStatsController.Index)
+	r.GET("/stats/pivot", controllers.PivotStatsController.Index)
+
+	r.GET("/forecast", controllers.ForecastController.Index)
+
+	r.GET("/imports/profiles", controllers.ImportProfileController.Index)
+	r.POST("/imports/profiles/:id", controllers.ImportProfileController.Update)
+	r.DELETE("/imports/profiles/:id", controllers.ImportProfileController.Delete)
+	r.POST("/imports/profiles", controllers.ImportProfileController.Create)
+	r.POST("/imports/csv", controllers.ImportController.ImportCSV)
+	r.POST("/imports/:format", controllers.ImportController.ImportStatement)
 
 	r.POST("/exports/excel", controllers.ExportController.ExportExcel)
+	r.POST("/exports/ods", controllers.ExportController.ExportODS)
+	r.POST("/exports/csv", controllers.ExportController.ExportCSV)
+	r.POST("/exports/json", controllers.ExportController.ExportJSON)
+	r.GET("/exports/journal/:format", controllers.ExportController.ExportJournal)
 
 	e.Logger.Fatal(e.Start(config.Config.Hostname + ":" + strconv.Itoa(int(config.Config.Port))))
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/trtstm/budgetr/controllers"
+	"github.com/trtstm/budgetr/log"
+)
+
+// runCommand executes the subcommand given on the command line.
+func runCommand(args []string) error {
+	switch args[0] {
+	case "import-csv":
+		return importCSVCommand(args[1:])
+	case "import":
+		return importStatementCommand(args[1:])
+	}
+
+	return fmt.Errorf("unknown command `%s`", args[0])
+}
+
+func importCSVCommand(args []string) error {
+	flags := flag.NewFlagSet("import-csv", flag.ContinueOnError)
+	profile := flags.String("profile", "", "name or id of the import profile")
+	account := flags.Uint("account", 0, "id of the account to import into, defaults to the first account")
+	duplicates := flags.String("duplicates", "flag", "what to do with likely duplicates: flag, reject or merge")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *profile == "" || flags.NArg() == 0 {
+		return errors.New("usage: budgetr import-csv -profile <profile> [-account <id>] [-duplicates <policy>] <file>...")
+	}
+
+	options := controllers.ImportOptions{AccountID: *account, Duplicates: controllers.DuplicatePolicy(*duplicates)}
+	for _, name := range flags.Args() {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+
+		result, err := controllers.ImportCSV(file, *profile, options)
+		file.Close()
+		if err != nil {
+			if result != nil {
+				log.Warnf("Partly imported '%s': %d created, %d merged, %d skipped, %d remaining.", name, result.Created, result.Merged, result.Skipped, result.Remaining)
+			}
+			return fmt.Errorf("could not import '%s': %v", name, err)
+		}
+
+		log.Infof("Imported '%s': %d created, %d merged, %d skipped.", name, result.Created, result.Merged, result.Skipped)
+	}
+
+	return nil
+}
+
+func importStatementCommand(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "statement format: coda, camt053, ofx, ledger, hledger or beancount")
+	account := flags.Uint("account", 0, "id of the account to import into, defaults to the first account")
+	duplicates := flags.String("duplicates", "flag", "what to do with likely duplicates: flag, reject or merge")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *format == "" || flags.NArg() == 0 {
+		return errors.New("usage: budgetr import -format <coda|camt053|ofx|ledger|hledger|beancount> [-account <id>] [-duplicates <policy>] <file>...")
+	}
+
+	options := controllers.ImportOptions{AccountID: *account, Duplicates: controllers.DuplicatePolicy(*duplicates)}
+	for _, name := range flags.Args() {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+
+		result, err := controllers.ImportStatement(file, *format, options)
+		file.Close()
+		if err != nil {
+			if result != nil {
+				log.Warnf("Partly imported '%s': %d created, %d merged, %d skipped, %d remaining.", name, result.Created, result.Merged, result.Skipped, result.Remaining)
+			}
+			return fmt.Errorf("could not import '%s': %v", name, err)
+		}
+
+		log.Infof("Imported '%s': %d created, %d merged, %d skipped.", name, result.Created, result.Merged, result.Skipped)
+	}
+
+	return nil
+}
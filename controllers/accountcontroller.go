@@ -0,0 +1,428 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+// maxBalanceSeriesDays limits the length of a balance series.
+const maxBalanceSeriesDays = 3660
+
+// AccountBalanceResponse holds an account together with its balance.
+type AccountBalanceResponse struct {
+	*AccountResponse
+	Balance float64 `json:"balance"`
+}
+
+// BalanceResponse is the balance of an account at the end of a day.
+type BalanceResponse struct {
+	Date    time.Time `json:"date"`
+	Balance float64   `json:"balance"`
+}
+
+type accountTotal struct {
+	ID    uint
+	Total float64
+}
+
+type movement struct {
+	Date   time.Time
+	Amount float64
+}
+
+// findAccount returns the account with the given id.
+// The default account is returned when id is 0.
+func findAccount(id uint) (*models.Account, error) {
+	account := &models.Account{}
+
+	q := db.DB.Order("id asc")
+	if id != 0 {
+		q = q.Where("id = ?", id)
+	}
+
+	if q = q.First(account); q.Error != nil {
+		return nil, q.Error
+	}
+
+	return account, nil
+}
+
+// accountBalances returns the change in balance of every account caused by
+// expenditures, income and transfers before end. The opening balance is not included.
+// A zero end includes everything.
+func accountBalances(end time.Time) (map[uint]float64, error) {
+	balances := map[uint]float64{}
+
+	queries := []struct {
+		table  string
+		group  string
+		selekt string
+		args   []interface{}
+		sign   float64
+	}{
+		{"expenditures", "account_id", "account_id AS id, SUM(CASE WHEN direction = ? THEN amount ELSE -amount END) AS total", []interface{}{models.DirectionIncome}, 1},
+		{"transfers", "to_account_id", "to_account_id AS id, SUM(amount) AS total", nil, 1},
+		{"transfers", "from_account_id", "from_account_id AS id, SUM(amount) AS total", nil, -1},
+	}
+
+	for _, query := range queries {
+		q := db.DB.Table(query.table).Where("deleted_at IS NULL").Group(query.group)
+		q = q.Select(query.selekt, query.args...)
+		if !end.IsZero() {
+			q = q.Where("date < ?", end)
+		}
+
+		totals := []*accountTotal{}
+		if q = q.Scan(&totals); q.Error != nil {
+			return nil, q.Error
+		}
+
+		for _, total := range totals {
+			balances[total.ID] += query.sign * total.Total
+		}
+	}
+
+	return balances, nil
+}
+
+// accountMovements returns all changes to the balance of an account in [start, end).
+func accountMovements(accountID uint, start time.Time, end time.Time) ([]*movement, error) {
+	movements := []*movement{}
+
+	q := db.DB.Table("expenditures").Where("deleted_at IS NULL AND account_id = ?", accountID)
+	q = q.Select("date, CASE WHEN direction = ? THEN amount ELSE -amount END AS amount", models.DirectionIncome)
+	if q = dateRangeQuery(start, end, q).Scan(&movements); q.Error != nil {
+		return nil, q.Error
+	}
+
+	transfers := []*movement{}
+	q = db.DB.Table("transfers").Where("deleted_at IS NULL AND (from_account_id = ? OR to_account_id = ?)", accountID, accountID)
+	q = q.Select("date, CASE WHEN to_account_id = ? THEN amount ELSE -amount END AS amount", accountID)
+	if q = dateRangeQuery(start, end, q).Scan(&transfers); q.Error != nil {
+		return nil, q.Error
+	}
+
+	return append(movements, transfers...), nil
+}
+
+type accountController struct {
+}
+
+func (c *accountController) Index(ctx echo.Context) error {
+	accounts := []*models.Account{}
+
+	if q := db.DB.Order("id asc").Find(&accounts); q.Error != nil {
+		log.Errorf("AccountController::Index Could not execute find query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	balances, err := accountBalances(time.Time{})
+	if err != nil {
+		log.Errorf("AccountController::Index Could not calculate balances: %v", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	result := []*AccountBalanceResponse{}
+	for i, resp := range TransformAccount(accounts...) {
+		result = append(result, &AccountBalanceResponse{
+			AccountResponse: resp,
+			Balance:         accounts[i].OpeningBalance + balances[accounts[i].ID],
+		})
+	}
+
+	log.Infof("AccountController::Index Returning %d accounts.", len(accounts))
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data": result,
+	})
+}
+
+func (c *accountController) Show(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("AccountController::Show Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	account := &models.Account{}
+	if q := db.DB.Where("id = ?", id).First(account); q.Error != nil {
+		if q.RecordNotFound() {
+			log.Infof("AccountController::Show Account '%d' not found.", id)
+			return ctx.NoContent(http.StatusNotFound)
+		}
+
+		log.Errorf("AccountController::Show First failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	balances, err := accountBalances(time.Time{})
+	if err != nil {
+		log.Errorf("AccountController::Show Could not calculate balances: %v", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("AccountController::Show Returning account: %+v.", account)
+	return ctx.JSON(http.StatusOK, &AccountBalanceResponse{
+		AccountResponse: TransformAccount(account)[0],
+		Balance:         account.OpeningBalance + balances[account.ID],
+	})
+}
+
+func (c *accountController) Balances(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("AccountController::Balances Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	start, end, err := parseDateRange(ctx)
+	if err != nil {
+		log.Infof("AccountController::Balances %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if start.IsZero() {
+		now := time.Now()
+		end = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+		start = end.AddDate(0, 0, -30)
+	}
+
+	if !start.Before(end) || end.Sub(start) > maxBalanceSeriesDays*24*time.Hour {
+		log.Infof("AccountController::Balances Invalid range [%s, %s).", start, end)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	account := &models.Account{}
+	if q := db.DB.Where("id = ?", id).First(account); q.Error != nil {
+		if q.RecordNotFound() {
+			log.Infof("AccountController::Balances Account '%d' not found.", id)
+			return ctx.NoContent(http.StatusNotFound)
+		}
+
+		log.Errorf("AccountController::Balances First failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	balances, err := accountBalances(start)
+	if err != nil {
+		log.Errorf("AccountController::Balances Could not calculate balances: %v", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	movements, err := accountMovements(account.ID, start, end)
+	if err != nil {
+		log.Errorf("AccountController::Balances Could not retrieve movements: %v", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	balance := account.OpeningBalance + balances[account.ID]
+	series := []*BalanceResponse{}
+
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	for day.Before(end) {
+		next := day.AddDate(0, 0, 1)
+		for _, m := range movements {
+			if !m.Date.Before(day) && m.Date.Before(next) {
+				balance += m.Amount
+			}
+		}
+
+		series = append(series, &BalanceResponse{Date: day, Balance: balance})
+		day = next
+	}
+
+	log.WithFields(log.Fields{"account": account.ID, "start": start, "end": end, "results": len(series)}).Infof("Returning balance series.")
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data": series,
+	})
+}
+
+func (c *accountController) Create(ctx echo.Context) error {
+	params := &struct {
+		Name           string             `json:"name" form:"name"`
+		Type           models.AccountType `json:"type" form:"type"`
+		OpeningBalance float64            `json:"opening_balance" form:"opening_balance"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("AccountController::Create Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	params.Name = strings.TrimSpace(params.Name)
+	if len(params.Name) == 0 {
+		log.Infof("AccountController::Create Name cant be empty.")
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if params.Type == "" {
+		params.Type = models.AccountChecking
+	}
+	if !params.Type.Valid() {
+		log.Infof("AccountController::Create Unknown account type `%s`.", params.Type)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	count := 0
+	if q := db.DB.Model(&models.Account{}).Where("name = ?", params.Name).Count(&count); q.Error != nil {
+		log.Errorf("AccountController::Create Count failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	if count > 0 {
+		log.Infof("AccountController::Create Account '%s' already exists.", params.Name)
+		return ctx.NoContent(http.StatusConflict)
+	}
+
+	account := &models.Account{
+		Name:           params.Name,
+		Type:           params.Type,
+		OpeningBalance: params.OpeningBalance,
+	}
+
+	if q := db.DB.Create(account); q.Error != nil {
+		log.Errorf("AccountController::Create Create failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("AccountController::Create Account created: %+v.", account)
+	return ctx.JSON(http.StatusCreated, TransformAccount(account)[0])
+}
+
+func (c *accountController) Update(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("AccountController::Update Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	account := &models.Account{}
+	if q := db.DB.First(account, "id = ?", id); q.Error != nil {
+		if q.RecordNotFound() {
+			log.Infof("AccountController::Update Account '%d' not found.", id)
+			return ctx.NoContent(http.StatusNotFound)
+		}
+
+		log.Errorf("AccountController::Update First failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	params := &struct {
+		Name           *string             `json:"name" form:"name"`
+		Type           *models.AccountType `json:"type" form:"type"`
+		OpeningBalance *float64            `json:"opening_balance" form:"opening_balance"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("AccountController::Update Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if params.Name != nil {
+		name := strings.TrimSpace(*params.Name)
+		if len(name) == 0 {
+			log.Infof("AccountController::Update Name cant be empty.")
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+
+		count := 0
+		if q := db.DB.Model(&models.Account{}).Where("name = ? AND id <> ?", name, account.ID).Count(&count); q.Error != nil {
+			log.Errorf("AccountController::Update Count failed: '%v'.", q.Error)
+			return ctx.NoContent(http.StatusInternalServerError)
+		}
+		if count > 0 {
+			log.Infof("AccountController::Update Account '%s' already exists.", name)
+			return ctx.NoContent(http.StatusConflict)
+		}
+
+		account.Name = name
+	}
+
+	if params.Type != nil {
+		if !params.Type.Valid() {
+			log.Infof("AccountController::Update Unknown account type `%s`.", *params.Type)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		account.Type = *params.Type
+	}
+
+	if params.OpeningBalance != nil {
+		account.OpeningBalance = *params.OpeningBalance
+	}
+
+	if q := db.DB.Save(account); q.Error != nil {
+		log.Errorf("AccountController::Update Update failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("AccountController::Update Updated: %+v.", account)
+	return ctx.JSON(http.StatusOK, TransformAccount(account)[0])
+}
+
+func (c *accountController) Delete(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("AccountController::Delete Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	// Without any account nothing can be created or imported anymore.
+	others := 0
+	if q := db.DB.Model(&models.Account{}).Where("id <> ?", id).Count(&others); q.Error != nil {
+		log.Errorf("AccountController::Delete Count failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	if others == 0 {
+		log.Infof("AccountController::Delete Account '%d' is the last account.", id)
+		return ctx.NoContent(http.StatusConflict)
+	}
+
+	// Deleted rows are counted as well, the account is removed for good
+	// and they would be left pointing to nothing.
+	expenditures := 0
+	if q := db.DB.Unscoped().Model(&models.Expenditure{}).Where("account_id = ?", id).Count(&expenditures); q.Error != nil {
+		log.Errorf("AccountController::Delete Count failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	transfers := 0
+	if q := db.DB.Unscoped().Model(&models.Transfer{}).Where("from_account_id = ? OR to_account_id = ?", id, id).Count(&transfers); q.Error != nil {
+		log.Errorf("AccountController::Delete Count failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	recurrings := 0
+	if q := db.DB.Unscoped().Model(&models.Recurring{}).Where("account_id = ?", id).Count(&recurrings); q.Error != nil {
+		log.Errorf("AccountController::Delete Count failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	if expenditures+transfers+recurrings > 0 {
+		log.Infof("AccountController::Delete Account '%d' is still in use.", id)
+		return ctx.NoContent(http.StatusConflict)
+	}
+
+	// Accounts are removed for good so their name can be reused.
+	q := db.DB.Unscoped().Where("id = ?", id).Delete(&models.Account{})
+	if q.Error != nil {
+		log.Errorf("AccountController::Delete Delete failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	if q.RowsAffected == 0 {
+		log.Infof("AccountController::Delete Could not delete account `%d`. Does not exist.", id)
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	log.Infof("AccountController::Delete Account '%d' deleted.", id)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// AccountController for /accounts endpoint.
+var AccountController accountController
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAccountControllerBalances(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		day := func(d int) time.Time {
+			return time.Date(2017, 5, d, 12, 0, 0, 0, time.Local)
+		}
+
+		checking, _ := findAccount(0)
+		savings := &models.Account{Name: "savings", Type: models.AccountSavings, OpeningBalance: 1000}
+		db.DB.Create(savings)
+
+		db.DB.Create(&models.Expenditure{Amount: 2000, Date: day(1), Direction: models.DirectionIncome, AccountID: checking.ID})
+		db.DB.Create(&models.Expenditure{Amount: 100, Date: day(2), AccountID: checking.ID})
+		db.DB.Create(&models.Expenditure{Amount: 50, Date: day(4), AccountID: checking.ID})
+		db.DB.Create(&models.Transfer{Amount: 500, Date: day(3), FromAccountID: checking.ID, ToAccountID: savings.ID})
+
+		Convey("Transfers do not count as spending.", t, func() {
+			r := httptest.NewRequest("GET", "/api/stats/cashflow", nil)
+			w := httptest.NewRecorder()
+			So(CashFlowStatsController.Index(e.NewContext(r, w)), ShouldBeNil)
+
+			answer := &CashFlowStatsResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(answer.Spending, ShouldEqual, 150)
+		})
+
+		Convey("Checking current balances.", t, func() {
+			r := httptest.NewRequest("GET", "/api/accounts", nil)
+			w := httptest.NewRecorder()
+			So(AccountController.Index(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			answer := &struct {
+				Data []*AccountBalanceResponse `json:"data"`
+			}{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(len(answer.Data), ShouldEqual, 2)
+			So(answer.Data[0].Name, ShouldEqual, models.DefaultAccountName)
+			So(answer.Data[0].Balance, ShouldEqual, 1350)
+			So(answer.Data[1].Name, ShouldEqual, "savings")
+			So(answer.Data[1].Balance, ShouldEqual, 1500)
+		})
+
+		Convey("Checking balance series.", t, func() {
+			params := url.Values{}
+			params.Set("start", time.Date(2017, 5, 2, 0, 0, 0, 0, time.Local).Format(time.RFC3339))
+			params.Set("end", time.Date(2017, 5, 5, 0, 0, 0, 0, time.Local).Format(time.RFC3339))
+
+			r := httptest.NewRequest("GET", "/api/accounts/:id/balances?"+params.Encode(), nil)
+			w := httptest.NewRecorder()
+			c := e.NewContext(r, w)
+			c.SetParamNames("id")
+			c.SetParamValues(strconv.Itoa(int(checking.ID)))
+			So(AccountController.Balances(c), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			answer := &struct {
+				Data []*BalanceResponse `json:"data"`
+			}{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(len(answer.Data), ShouldEqual, 3)
+			So(answer.Data[0].Balance, ShouldEqual, 1900)
+			So(answer.Data[1].Balance, ShouldEqual, 1400)
+			So(answer.Data[2].Balance, ShouldEqual, 1350)
+		})
+
+		Convey("Accounts in use can not be deleted.", t, func() {
+			r := httptest.NewRequest("DELETE", "/api/accounts/:id", nil)
+			w := httptest.NewRecorder()
+			c := e.NewContext(r, w)
+			c.SetParamNames("id")
+			c.SetParamValues(strconv.Itoa(int(savings.ID)))
+			So(AccountController.Delete(c), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusConflict)
+		})
+	})
+}
+
+func TestAccountControllerDelete(t *testing.T) {
+	e := echo.New()
+
+	deleteAccount := func(id uint) int {
+		r := httptest.NewRequest("DELETE", "/api/accounts/:id", nil)
+		w := httptest.NewRecorder()
+		c := e.NewContext(r, w)
+		c.SetParamNames("id")
+		c.SetParamValues(strconv.Itoa(int(id)))
+		So(AccountController.Delete(c), ShouldBeNil)
+		return w.Code
+	}
+
+	withDb(func() {
+		checking, _ := findAccount(0)
+
+		Convey("The last account can not be deleted.", t, func() {
+			So(deleteAccount(checking.ID), ShouldEqual, http.StatusConflict)
+		})
+
+		Convey("Accounts used by deleted expenditures can not be deleted.", t, func() {
+			savings := &models.Account{Name: "savings", Type: models.AccountSavings}
+			db.DB.Create(savings)
+			expenditure := &models.Expenditure{Amount: 10, Date: time.Now(), AccountID: savings.ID}
+			db.DB.Create(expenditure)
+			db.DB.Delete(expenditure)
+
+			So(deleteAccount(savings.ID), ShouldEqual, http.StatusConflict)
+		})
+
+		Convey("Unused accounts are deleted.", t, func() {
+			cash := &models.Account{Name: "cash", Type: models.AccountCash}
+			db.DB.Create(cash)
+
+			So(deleteAccount(cash.ID), ShouldEqual, http.StatusOK)
+			So(deleteAccount(cash.ID), ShouldEqual, http.StatusNotFound)
+		})
+	})
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+type budgetController struct {
+}
+
+func (c *budgetController) Index(ctx echo.Context) error {
+	budgets := []*models.Budget{}
+
+	q := db.DB.Preload("Category")
+	if period := ctx.QueryParam("period"); len(period) > 0 {
+		if _, _, err := models.ParseBudgetPeriod(period); err != nil {
+			log.Infof("BudgetController::Index Could not parse period `%s`: '%v'.", period, err)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		q = q.Where("period = ?", period)
+	}
+
+	if q = q.Order("period desc").Find(&budgets); q.Error != nil {
+		log.Errorf("BudgetController::Index Could not execute find query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("BudgetController::Index Returning %d budgets.", len(budgets))
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data": TransformBudget(budgets...),
+	})
+}
+
+func (c *budgetController) Create(ctx echo.Context) error {
+	params := &struct {
+		Category string  `json:"category" form:"category"`
+		Period   string  `json:"period" form:"period"`
+		Limit    float64 `json:"limit" form:"limit"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("BudgetController::Create Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	params.Category = strings.TrimSpace(params.Category)
+	if len(params.Category) == 0 {
+		log.Infof("BudgetController::Create Category cant be empty.")
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if _, _, err := models.ParseBudgetPeriod(params.Period); err != nil {
+		log.Infof("BudgetController::Create Could not parse period `%s`: '%v'.", params.Period, err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if params.Limit < 0 {
+		log.Infof("BudgetController::Create Limit cant be negative.")
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	category := &models.Category{Name: params.Category}
+	if q := db.DB.FirstOrCreate(category, "name = ?", category.Name); q.Error != nil {
+		log.Errorf("BudgetController::Create FirstOrCreate failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	count := 0
+	if q := db.DB.Model(&models.Budget{}).Where("category_id = ? AND period = ?", category.ID, params.Period).Count(&count); q.Error != nil {
+		log.Errorf("BudgetController::Create Count failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	if count > 0 {
+		log.Infof("BudgetController::Create Budget for '%s' in '%s' already exists.", category.Name, params.Period)
+		return ctx.NoContent(http.StatusConflict)
+	}
+
+	budget := &models.Budget{
+		Category: category,
+		Period:   params.Period,
+		Amount:   params.Limit,
+	}
+
+	if q := db.DB.Create(budget); q.Error != nil {
+		log.Errorf("BudgetController::Create Create failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("BudgetController::Create Budget created: %+v.", budget)
+	return ctx.JSON(http.StatusCreated, TransformBudget(budget)[0])
+}
+
+func (c *budgetController) Update(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("BudgetController::Update Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	budget := &models.Budget{}
+	if q := db.DB.Preload("Category").First(budget, "id = ?", id); q.Error != nil {
+		if q.RecordNotFound() {
+			log.Infof("BudgetController::Update Budget '%d' not found.", id)
+			return ctx.NoContent(http.StatusNotFound)
+		}
+
+		log.Errorf("BudgetController::Update First failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	params := &struct {
+		Period *string  `json:"period" form:"period"`
+		Limit  *float64 `json:"limit" form:"limit"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("BudgetController::Update Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if params.Period != nil && *params.Period != budget.Period {
+		if _, _, err := models.ParseBudgetPeriod(*params.Period); err != nil {
+			log.Infof("BudgetController::Update Could not parse period `%s`: '%v'.", *params.Period, err)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+
+		count := 0
+		if q := db.DB.Model(&models.Budget{}).Where("category_id = ? AND period = ?", budget.CategoryID, *params.Period).Count(&count); q.Error != nil {
+			log.Errorf("BudgetController::Update Count failed: '%v'.", q.Error)
+			return ctx.NoContent(http.StatusInternalServerError)
+		}
+		if count > 0 {
+			log.Infof("BudgetController::Update Budget for category '%d' in '%s' already exists.", budget.CategoryID, *params.Period)
+			return ctx.NoContent(http.StatusConflict)
+		}
+
+		budget.Period = *params.Period
+	}
+
+	if params.Limit != nil {
+		if *params.Limit < 0 {
+			log.Infof("BudgetController::Update Limit cant be negative.")
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		budget.Amount = *params.Limit
+	}
+
+	if q := db.DB.Save(budget); q.Error != nil {
+		log.Errorf("BudgetController::Update Update failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("BudgetController::Update Updated: %+v.", budget)
+	return ctx.JSON(http.StatusOK, TransformBudget(budget)[0])
+}
+
+func (c *budgetController) Delete(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("BudgetController::Delete Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	// Budgets are removed for good so the (category, period) pair can be reused.
+	q := db.DB.Unscoped().Where("id = ?", id).Delete(&models.Budget{})
+	if q.Error != nil {
+		log.Errorf("BudgetController::Delete Delete failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	if q.RowsAffected == 0 {
+		log.Infof("BudgetController::Delete Could not delete budget `%d`. Does not exist.", id)
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	log.Infof("BudgetController::Delete Budget '%d' deleted.", id)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// BudgetController for /budgets endpoint.
+var BudgetController budgetController
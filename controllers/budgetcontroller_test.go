@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBudgetControllerCreate(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		post := func(data string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest("POST", "/api/budgets", bytes.NewReader([]byte(data)))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			So(BudgetController.Create(e.NewContext(r, w)), ShouldBeNil)
+			return w
+		}
+
+		Convey("Creating budgets.", t, func() {
+			w := post(`{"category": "food", "period": "2017-05", "limit": 300}`)
+			So(w.Code, ShouldEqual, http.StatusCreated)
+
+			answer := &BudgetResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(answer.Period, ShouldEqual, "2017-05")
+			So(answer.Limit, ShouldEqual, 300)
+			So(answer.Category, ShouldNotBeNil)
+			So(answer.Category.Name, ShouldEqual, "food")
+
+			So(post(`{"category": "food", "period": "2017-05", "limit": 100}`).Code, ShouldEqual, http.StatusConflict)
+			So(post(`{"category": "food", "period": "2017-06", "limit": 100}`).Code, ShouldEqual, http.StatusCreated)
+			So(post(`{"category": "food", "period": "05/2017", "limit": 100}`).Code, ShouldEqual, http.StatusBadRequest)
+			So(post(`{"category": "", "period": "2017-07", "limit": 100}`).Code, ShouldEqual, http.StatusBadRequest)
+			So(post(`{"category": "food", "period": "2017-07", "limit": -1}`).Code, ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
+
+func TestBudgetStatsControllerIndex(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		food := &models.Category{Name: "food"}
+		car := &models.Category{Name: "car"}
+		db.DB.Create(food)
+		db.DB.Create(car)
+
+		db.DB.Create(&models.Budget{CategoryID: food.ID, Period: "2017-05", Amount: 200})
+		db.DB.Create(&models.Budget{CategoryID: car.ID, Period: "2017-05", Amount: 0})
+
+		db.DB.Create(&models.Expenditure{Amount: 50, Date: time.Date(2017, 5, 1, 12, 0, 0, 0, time.Local), CategoryID: food.ID})
+		db.DB.Create(&models.Expenditure{Amount: 100, Date: time.Date(2017, 5, 31, 12, 0, 0, 0, time.Local), CategoryID: food.ID})
+		db.DB.Create(&models.Expenditure{Amount: 75, Date: time.Date(2017, 6, 1, 12, 0, 0, 0, time.Local), CategoryID: food.ID})
+		db.DB.Create(&models.Expenditure{Amount: 20, Date: time.Date(2017, 5, 10, 12, 0, 0, 0, time.Local), CategoryID: car.ID})
+
+		Convey("Comparing budgets with spending.", t, func() {
+			r := httptest.NewRequest("GET", "/api/stats/budgets?period=2017-05", nil)
+			w := httptest.NewRecorder()
+			So(BudgetStatsController.Index(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			answer := []*BudgetStatsResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(&answer), ShouldBeNil)
+			So(len(answer), ShouldEqual, 2)
+
+			stats := map[string]*BudgetStatsResponse{}
+			for _, stat := range answer {
+				stats[stat.Category.Name] = stat
+			}
+
+			So(stats["food"].Spent, ShouldEqual, 150)
+			So(stats["food"].Remaining, ShouldEqual, 50)
+			So(stats["food"].Percentage.Valid, ShouldBeTrue)
+			So(stats["food"].Percentage.Float64, ShouldEqual, 75)
+
+			So(stats["car"].Spent, ShouldEqual, 20)
+			So(stats["car"].Remaining, ShouldEqual, -20)
+			So(stats["car"].Percentage.Valid, ShouldBeFalse)
+		})
+
+		Convey("Invalid period.", t, func() {
+			r := httptest.NewRequest("GET", "/api/stats/budgets?period=may", nil)
+			w := httptest.NewRecorder()
+			So(BudgetStatsController.Index(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+// BudgetStatsResponse compares the budget of a category with what was spent.
+type BudgetStatsResponse struct {
+	ID         uint               `json:"id"`
+	Category   *CategoryResponse  `json:"category"`
+	Period     string             `json:"period"`
+	Limit      float64            `json:"limit"`
+	Spent      float64            `json:"spent"`
+	Remaining  float64            `json:"remaining"`
+	Percentage models.NullFloat64 `json:"percentage"`
+}
+
+type budgetStatsController struct {
+}
+
+func (c *budgetStatsController) Index(ctx echo.Context) error {
+	period := ctx.QueryParam("period")
+	if len(period) == 0 {
+		period = time.Now().Format(models.BudgetPeriodFormat)
+	}
+
+	start, end, err := models.ParseBudgetPeriod(period)
+	if err != nil {
+		log.Infof("BudgetStatsController::Index Failed to parse period `%s`: %v", period, err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	budgets := []*models.Budget{}
+	if q := db.DB.Preload("Category").Where("period = ?", period).Find(&budgets); q.Error != nil {
+		log.Errorf("BudgetStatsController::Index Could not retrieve budgets: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	stats := []*CategoryStatsResponse{}
+	q := dateRangeQuery(start, end, categoryStatsQuery(models.DirectionExpense))
+	if q = q.Scan(&stats); q.Error != nil {
+		log.Errorf("BudgetStatsController::Index Could not execute query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	spent := map[uint]float64{}
+	for _, stat := range stats {
+		spent[stat.ID] = stat.Total
+	}
+
+	result := []*BudgetStatsResponse{}
+	for _, budget := range budgets {
+		resp := &BudgetStatsResponse{
+			ID:        budget.ID,
+			Period:    budget.Period,
+			Limit:     budget.Amount,
+			Spent:     spent[budget.CategoryID],
+			Remaining: budget.Amount - spent[budget.CategoryID],
+		}
+		if budget.Category != nil {
+			resp.Category = TransformCategory(budget.Category)[0]
+		}
+		if budget.Amount != 0 {
+			resp.Percentage.Set(resp.Spent / budget.Amount * 100)
+		}
+
+		result = append(result, resp)
+	}
+
+	log.WithFields(log.Fields{"period": period, "results": len(result)}).Infof("Returning budget statistics.")
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// BudgetStatsController for /stats/budgets endpoint.
+var BudgetStatsController budgetStatsController
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+// CashFlowStatsResponse contains the income, spending and net savings of a period.
+type CashFlowStatsResponse struct {
+	Income   float64 `json:"income"`
+	Spending float64 `json:"spending"`
+	Net      float64 `json:"net"`
+}
+
+type cashFlowStatsController struct {
+}
+
+func (c *cashFlowStatsController) Index(ctx echo.Context) error {
+	start, end, err := parseDateRange(ctx)
+	if err != nil {
+		log.Infof("CashFlowStatsController::Index %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	totals := []*struct {
+		Direction models.Direction
+		Total     float64
+	}{}
+
+	q := db.DB.Table("expenditures")
+	q = q.Where("deleted_at IS NULL")
+	q = q.Group("direction")
+	q = q.Select("direction, SUM(amount) AS total")
+	if !start.IsZero() {
+		q = dateRangeQuery(start, end, q)
+	}
+
+	if q = q.Scan(&totals); q.Error != nil {
+		log.Errorf("CashFlowStatsController::Index Could not execute query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	stats := &CashFlowStatsResponse{}
+	for _, total := range totals {
+		switch total.Direction {
+		case models.DirectionIncome:
+			stats.Income += total.Total
+		default:
+			stats.Spending += total.Total
+		}
+	}
+	stats.Net = stats.Income - stats.Spending
+
+	logFields := log.Fields{
+		"income":   stats.Income,
+		"spending": stats.Spending,
+	}
+	if !start.IsZero() {
+		logFields["start"] = start
+		logFields["end"] = end
+	}
+
+	log.WithFields(logFields).Infof("Returning cash flow statistics.")
+	return ctx.JSON(http.StatusOK, stats)
+}
+
+// CashFlowStatsController for /stats/cashflow endpoint.
+var CashFlowStatsController cashFlowStatsController
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCashFlowStatsControllerIndex(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		now := time.Now()
+		tests := []test{}
+
+		for _, amount := range []string{"1500", "250.5"} {
+			tests = append(tests, test{
+				URL:                "/api/incomes",
+				Method:             "post",
+				Endpoint:           IncomeController.Create,
+				ContentType:        "application/json",
+				PostData:           `{"amount": ` + amount + `, "date": "` + now.Format(time.RFC3339) + `", "category": "salary"}`,
+				ExpectedStatusCode: http.StatusCreated,
+			})
+		}
+
+		tests = append(tests, test{
+			URL:                "/api/expenditures",
+			Method:             "post",
+			Endpoint:           ExpenditureController.Create,
+			ContentType:        "application/json",
+			PostData:           `{"amount": 300, "date": "` + now.Format(time.RFC3339) + `", "category": "rent"}`,
+			ExpectedStatusCode: http.StatusCreated,
+		})
+
+		Convey("Creating income and expenditures.", t, func() {
+			for _, test := range tests {
+				doTest(&test)
+			}
+		})
+
+		Convey("Income is kept apart from expenditures.", t, func() {
+			r := httptest.NewRequest("GET", "/api/expenditures", nil)
+			w := httptest.NewRecorder()
+			So(ExpenditureController.Index(e.NewContext(r, w)), ShouldBeNil)
+
+			answer := &expenditureListResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(len(answer.Data), ShouldEqual, 1)
+			So(answer.Data[0].Direction, ShouldEqual, models.DirectionExpense)
+
+			r = httptest.NewRequest("GET", "/api/incomes", nil)
+			w = httptest.NewRecorder()
+			So(IncomeController.Index(e.NewContext(r, w)), ShouldBeNil)
+
+			answer = &expenditureListResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(len(answer.Data), ShouldEqual, 2)
+			So(answer.Data[0].Direction, ShouldEqual, models.DirectionIncome)
+		})
+
+		Convey("Checking cash flow.", t, func() {
+			r := httptest.NewRequest("GET", "/api/stats/cashflow", nil)
+			w := httptest.NewRecorder()
+			So(CashFlowStatsController.Index(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			answer := &CashFlowStatsResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(answer.Income, ShouldEqual, 1750.5)
+			So(answer.Spending, ShouldEqual, 300)
+			So(answer.Net, ShouldEqual, 1450.5)
+		})
+	})
+}
@@ -5,23 +5,140 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/jinzhu/gorm"
 	"github.com/labstack/echo"
 	"github.com/trtstm/budgetr/db"
 	"github.com/trtstm/budgetr/log"
 	"github.com/trtstm/budgetr/models"
 )
 
+// categoryNameTaken returns whether another category than id already uses name.
+// Deleted categories count as well because of the unique constraint.
+func categoryNameTaken(name string, id uint) (bool, error) {
+	count := 0
+	q := db.DB.Unscoped().Model(&models.Category{}).Where("name = ? AND id <> ?", name, id).Count(&count)
+	return count > 0, q.Error
+}
+
+// removeCategory moves everything that refers to category to target and deletes category.
+// Without target, expenditures become uncategorized and the budgets and rules
+// of category are deleted. Subcategories move to the parent of category.
+func removeCategory(tx *gorm.DB, category *models.Category, target *models.Category) error {
+	var targetID uint
+	if target != nil {
+		targetID = target.ID
+	}
+
+	if q := tx.Model(&models.Expenditure{}).Unscoped().Where("category_id = ?", category.ID).UpdateColumn("category_id", targetID); q.Error != nil {
+		return q.Error
+	}
+	if q := tx.Model(&models.ExpenditureSplit{}).Unscoped().Where("category_id = ?", category.ID).UpdateColumn("category_id", targetID); q.Error != nil {
+		return q.Error
+	}
+	if q := tx.Model(&models.Category{}).Where("parent_id = ?", category.ID).UpdateColumn("parent_id", category.ParentID); q.Error != nil {
+		return q.Error
+	}
+	if q := tx.Model(&models.Recurring{}).Unscoped().Where("category_id = ?", category.ID).UpdateColumn("category_id", targetID); q.Error != nil {
+		return q.Error
+	}
+
+	if target == nil {
+		if q := tx.Unscoped().Where("category_id = ?", category.ID).Delete(&models.CategoryRule{}); q.Error != nil {
+			return q.Error
+		}
+		if q := tx.Unscoped().Where("category_id = ?", category.ID).Delete(&models.Budget{}); q.Error != nil {
+			return q.Error
+		}
+	} else {
+		if q := tx.Model(&models.CategoryRule{}).Unscoped().Where("category_id = ?", category.ID).UpdateColumn("category_id", targetID); q.Error != nil {
+			return q.Error
+		}
+
+		// Budgets of the same month are added up, there can only be one per month.
+		budgets := []*models.Budget{}
+		if q := tx.Where("category_id = ?", category.ID).Find(&budgets); q.Error != nil {
+			return q.Error
+		}
+		for _, budget := range budgets {
+			existing := &models.Budget{}
+			q := tx.Where("category_id = ? AND period = ?", targetID, budget.Period).First(existing)
+			if q.Error != nil && !q.RecordNotFound() {
+				return q.Error
+			}
+
+			if q.RecordNotFound() {
+				q = tx.Model(budget).UpdateColumn("category_id", targetID)
+			} else {
+				if q = tx.Model(existing).UpdateColumn("amount", existing.Amount+budget.Amount); q.Error == nil {
+					q = tx.Unscoped().Delete(budget)
+				}
+			}
+			if q.Error != nil {
+				return q.Error
+			}
+		}
+	}
+
+	// Hard delete so the name can be used again.
+	return tx.Unscoped().Delete(category).Error
+}
+
+// findCategory loads the category with the id in param.
+// The returned status is http.StatusOK when it was found.
+func findCategory(action string, param string) (*models.Category, int) {
+	id, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		log.Infof("CategoryController::%s Could not parse id `%s`: '%v'.", action, param, err)
+		return nil, http.StatusBadRequest
+	}
+
+	category := &models.Category{}
+	if q := db.DB.Where("id = ?", id).First(category); q.Error != nil {
+		if q.RecordNotFound() {
+			log.Infof("CategoryController::%s Category '%d' not found.", action, id)
+			return nil, http.StatusNotFound
+		}
+
+		log.Errorf("CategoryController::%s First failed: '%v'.", action, q.Error)
+		return nil, http.StatusInternalServerError
+	}
+
+	return category, http.StatusOK
+}
+
 type categoryController struct {
 }
 
+// Index returns the categories that are not archived, or all of them when archived is set.
+// With tree set, only the top level categories are returned and their
+// subcategories are nested in children.
 func (c *categoryController) Index(ctx echo.Context) error {
 	categories := []*models.Category{}
 
-	if q := db.DB.Find(&categories); q.Error != nil {
+	q := db.DB
+	if archived, _ := strconv.ParseBool(ctx.QueryParam("archived")); !archived {
+		q = q.Where("archived = ?", false)
+	}
+
+	if q = q.Find(&categories); q.Error != nil {
 		log.Errorf("CategoryController::Index Could not execute find query: %v", q.Error)
 		return ctx.NoContent(http.StatusInternalServerError)
 	}
 
+	if tree, _ := strconv.ParseBool(ctx.QueryParam("tree")); tree {
+		byID := map[uint]*models.Category{}
+		for _, category := range categories {
+			byID[category.ID] = category
+		}
+
+		log.Infof("CategoryController::Index Returning tree of %d categories.", len(categories))
+		return ctx.JSON(http.StatusOK, echo.Map{
+			"data": buildCategoryTree(byID),
+		})
+	}
+
+	sortCategories(categories)
+
 	log.Infof("CategoryController::Index Returning %d categories.", len(categories))
 	return ctx.JSON(http.StatusOK, echo.Map{
 		"data": TransformCategory(categories...),
@@ -36,7 +153,13 @@ func (c *categoryController) Update(ctx echo.Context) error {
 	}
 
 	params := &struct {
-		Name string `json:"name" form:"name"`
+		Name *string `json:"name" form:"name"`
+		// Parent moves the category, 0 makes it a top level category.
+		Parent    *uint   `json:"parent" form:"parent"`
+		Color     *string `json:"color" form:"color"`
+		Icon      *string `json:"icon" form:"icon"`
+		SortOrder *int    `json:"sort_order" form:"sort_order"`
+		Archived  *bool   `json:"archived" form:"archived"`
 	}{}
 
 	if err := ctx.Bind(params); err != nil {
@@ -52,13 +175,54 @@ func (c *categoryController) Update(ctx echo.Context) error {
 	}
 
 	oldName := category.Name
-	category.Name = strings.TrimSpace(params.Name)
+	if params.Name != nil {
+		category.Name = strings.TrimSpace(*params.Name)
+	}
 
 	if len(category.Name) == 0 {
 		log.Infof("categoryController::Update Name cant be empty.")
 		return ctx.NoContent(http.StatusBadRequest)
 	}
 
+	if taken, err := categoryNameTaken(category.Name, category.ID); err != nil {
+		log.Errorf("CategoryController::Update Count failed: '%v'.", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	} else if taken {
+		log.Infof("categoryController::Update Category '%s' already exists.", category.Name)
+		return ctx.NoContent(http.StatusConflict)
+	}
+
+	if params.Parent != nil {
+		categories, err := loadCategories()
+		if err != nil {
+			log.Errorf("CategoryController::Update Could not load categories: %v", err)
+			return ctx.NoContent(http.StatusInternalServerError)
+		}
+
+		if err := checkCategoryParent(categories, category.ID, *params.Parent); err != nil {
+			log.Infof("categoryController::Update Invalid parent '%d': '%v'.", *params.Parent, err)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		category.ParentID = *params.Parent
+	}
+
+	if params.Color != nil {
+		if !validCategoryColor(*params.Color) {
+			log.Infof("categoryController::Update Invalid color `%s`.", *params.Color)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		category.Color = *params.Color
+	}
+	if params.Icon != nil {
+		category.Icon = strings.TrimSpace(*params.Icon)
+	}
+	if params.SortOrder != nil {
+		category.SortOrder = *params.SortOrder
+	}
+	if params.Archived != nil {
+		category.Archived = *params.Archived
+	}
+
 	if q := db.DB.Save(category); q.Error != nil {
 		log.Errorf("CategoryController::Update Could not save category: %v", q.Error)
 		return ctx.NoContent(http.StatusInternalServerError)
@@ -68,5 +232,151 @@ func (c *categoryController) Update(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, TransformCategory(category)[0])
 }
 
+func (c *categoryController) Create(ctx echo.Context) error {
+	params := &struct {
+		Name      string `json:"name" form:"name"`
+		Parent    uint   `json:"parent" form:"parent"`
+		Color     string `json:"color" form:"color"`
+		Icon      string `json:"icon" form:"icon"`
+		SortOrder int    `json:"sort_order" form:"sort_order"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("CategoryController::Create Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	category := &models.Category{
+		Name:      strings.TrimSpace(params.Name),
+		Color:     params.Color,
+		Icon:      strings.TrimSpace(params.Icon),
+		SortOrder: params.SortOrder,
+	}
+	if len(category.Name) == 0 {
+		log.Infof("CategoryController::Create Name cant be empty.")
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if !validCategoryColor(category.Color) {
+		log.Infof("CategoryController::Create Invalid color `%s`.", category.Color)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if params.Parent != 0 {
+		parent, status := findCategory("Create", strconv.Itoa(int(params.Parent)))
+		if status != http.StatusOK {
+			if status == http.StatusNotFound {
+				status = http.StatusBadRequest
+			}
+			return ctx.NoContent(status)
+		}
+		category.ParentID = parent.ID
+	}
+
+	if taken, err := categoryNameTaken(category.Name, 0); err != nil {
+		log.Errorf("CategoryController::Create Count failed: '%v'.", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	} else if taken {
+		log.Infof("CategoryController::Create Category '%s' already exists.", category.Name)
+		return ctx.NoContent(http.StatusConflict)
+	}
+
+	if q := db.DB.Create(category); q.Error != nil {
+		log.Errorf("CategoryController::Create Create failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("CategoryController::Create Category created: %+v.", category)
+	return ctx.JSON(http.StatusCreated, TransformCategory(category)[0])
+}
+
+// Delete deletes a category. The expenditures of the category are moved to the
+// category given in reassign, or become uncategorized when it is not given.
+func (c *categoryController) Delete(ctx echo.Context) error {
+	category, status := findCategory("Delete", ctx.Param("id"))
+	if status != http.StatusOK {
+		return ctx.NoContent(status)
+	}
+
+	var target *models.Category
+	if reassign := ctx.QueryParam("reassign"); len(reassign) > 0 {
+		if target, status = findCategory("Delete", reassign); status != http.StatusOK {
+			if status == http.StatusNotFound {
+				status = http.StatusBadRequest
+			}
+			return ctx.NoContent(status)
+		}
+
+		if target.ID == category.ID {
+			log.Infof("CategoryController::Delete Can not reassign category '%d' to itself.", category.ID)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+	}
+
+	tx := db.DB.Begin()
+	if err := removeCategory(tx, category, target); err != nil {
+		tx.Rollback()
+		log.Errorf("CategoryController::Delete Could not delete category: '%v'.", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	if q := tx.Commit(); q.Error != nil {
+		log.Errorf("CategoryController::Delete Commit failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("CategoryController::Delete Category '%d' deleted.", category.ID)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Merge moves all expenditures, budgets and rules of a category into another one and deletes it.
+func (c *categoryController) Merge(ctx echo.Context) error {
+	category, status := findCategory("Merge", ctx.Param("id"))
+	if status != http.StatusOK {
+		return ctx.NoContent(status)
+	}
+
+	params := &struct {
+		Into uint `json:"into" form:"into"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("CategoryController::Merge Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	target, status := findCategory("Merge", strconv.Itoa(int(params.Into)))
+	if status != http.StatusOK {
+		if status == http.StatusNotFound {
+			status = http.StatusBadRequest
+		}
+		return ctx.NoContent(status)
+	}
+
+	if target.ID == category.ID {
+		log.Infof("CategoryController::Merge Can not merge category '%d' into itself.", category.ID)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	tx := db.DB.Begin()
+	if err := removeCategory(tx, category, target); err != nil {
+		tx.Rollback()
+		log.Errorf("CategoryController::Merge Could not merge category: '%v'.", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	if q := tx.Commit(); q.Error != nil {
+		log.Errorf("CategoryController::Merge Commit failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	// The parent of target may have changed when it was a subcategory of category.
+	if q := db.DB.First(target, target.ID); q.Error != nil {
+		log.Errorf("CategoryController::Merge Could not reload category '%d': '%v'.", target.ID, q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("CategoryController::Merge Merged category '%s' into '%s'.", category.Name, target.Name)
+	return ctx.JSON(http.StatusOK, TransformCategory(target)[0])
+}
+
 // CategoryController for /categories endpoint.
 var CategoryController categoryController
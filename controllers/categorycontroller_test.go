@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCategoryHierarchy(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		car := &models.Category{Name: "Car"}
+		db.DB.Create(car)
+		fuel := &models.Category{Name: "Fuel", ParentID: car.ID}
+		db.DB.Create(fuel)
+		insurance := &models.Category{Name: "Insurance"}
+		db.DB.Create(insurance)
+
+		date := time.Date(2017, 4, 1, 12, 0, 0, 0, time.Local)
+		db.DB.Create(&models.Expenditure{Amount: 60, Date: date, Category: fuel})
+		db.DB.Create(&models.Expenditure{Amount: 400, Date: date, Category: insurance})
+
+		update := func(id uint, body string) int {
+			r := httptest.NewRequest("POST", "/api/categories/:id", strings.NewReader(body))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			c := e.NewContext(r, w)
+			c.SetParamNames("id")
+			c.SetParamValues(strconv.Itoa(int(id)))
+			So(CategoryController.Update(c), ShouldBeNil)
+			return w.Code
+		}
+
+		Convey("Moving a category under a parent.", t, func() {
+			So(update(insurance.ID, `{"name": "Insurance", "parent": `+strconv.Itoa(int(car.ID))+`}`), ShouldEqual, http.StatusOK)
+			So(update(car.ID, `{"name": "Car", "parent": `+strconv.Itoa(int(fuel.ID))+`}`), ShouldEqual, http.StatusBadRequest)
+			So(update(car.ID, `{"name": "Car", "parent": 1234}`), ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Getting the category tree.", t, func() {
+			r := httptest.NewRequest("GET", "/api/categories?tree=true", nil)
+			w := httptest.NewRecorder()
+			So(CategoryController.Index(e.NewContext(r, w)), ShouldBeNil)
+
+			answer := &struct {
+				Data []*CategoryResponse `json:"data"`
+			}{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(len(answer.Data), ShouldEqual, 1)
+			So(answer.Data[0].Name, ShouldEqual, "Car")
+			So(len(answer.Data[0].Children), ShouldEqual, 2)
+			So(answer.Data[0].Children[0].Name, ShouldEqual, "Fuel")
+			So(*answer.Data[0].Children[0].Parent, ShouldEqual, car.ID)
+		})
+
+		Convey("Rolling up category totals.", t, func() {
+			r := httptest.NewRequest("GET", "/api/stats/categories", nil)
+			w := httptest.NewRecorder()
+			So(CategoryStatsController.Index(e.NewContext(r, w)), ShouldBeNil)
+
+			stats := []*CategoryStatsResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(&stats), ShouldBeNil)
+
+			byName := map[string]*CategoryStatsResponse{}
+			for _, stat := range stats {
+				byName[stat.Name.String] = stat
+			}
+			So(byName["Car"].Total, ShouldEqual, 0)
+			So(byName["Car"].RollupTotal, ShouldEqual, 460)
+			So(byName["Fuel"].Total, ShouldEqual, 60)
+			So(byName["Fuel"].RollupTotal, ShouldEqual, 60)
+		})
+	})
+}
+
+func TestCategoryControllerDeleteAndMerge(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		food := &models.Category{Name: "Food"}
+		groceries := &models.Category{Name: "Groceries"}
+		restaurant := &models.Category{Name: "Restaurant"}
+		db.DB.Create(food)
+		db.DB.Create(groceries)
+		db.DB.Create(restaurant)
+
+		date := time.Date(2017, 4, 1, 12, 0, 0, 0, time.Local)
+		db.DB.Create(&models.Expenditure{Amount: 60, Date: date, Category: food})
+		db.DB.Create(&models.Expenditure{Amount: 40, Date: date, Category: groceries})
+		db.DB.Create(&models.Expenditure{Amount: 30, Date: date, Category: restaurant})
+		db.DB.Create(&models.Budget{CategoryID: food.ID, Period: "2017-04", Amount: 100})
+		db.DB.Create(&models.Budget{CategoryID: groceries.ID, Period: "2017-04", Amount: 200})
+
+		do := func(endpoint echo.HandlerFunc, method string, target string, id uint, body string) int {
+			r := httptest.NewRequest(method, target, strings.NewReader(body))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			c := e.NewContext(r, w)
+			c.SetParamNames("id")
+			c.SetParamValues(strconv.Itoa(int(id)))
+			So(endpoint(c), ShouldBeNil)
+			return w.Code
+		}
+
+		Convey("Creating and renaming categories.", t, func() {
+			So(do(CategoryController.Create, "POST", "/api/categories", 0, `{"name": "Transport"}`), ShouldEqual, http.StatusCreated)
+			So(do(CategoryController.Create, "POST", "/api/categories", 0, `{"name": "Food"}`), ShouldEqual, http.StatusConflict)
+			So(do(CategoryController.Update, "POST", "/api/categories/:id", restaurant.ID, `{"name": "Food"}`), ShouldEqual, http.StatusConflict)
+		})
+
+		Convey("Merging a category.", t, func() {
+			So(do(CategoryController.Merge, "POST", "/api/categories/:id/merge", groceries.ID, `{"into": `+strconv.Itoa(int(food.ID))+`}`), ShouldEqual, http.StatusOK)
+
+			count := 0
+			db.DB.Model(&models.Expenditure{}).Where("category_id = ?", food.ID).Count(&count)
+			So(count, ShouldEqual, 2)
+
+			budget := &models.Budget{}
+			db.DB.Where("category_id = ?", food.ID).First(budget)
+			So(budget.Amount, ShouldEqual, 300)
+
+			db.DB.Model(&models.Category{}).Where("id = ?", groceries.ID).Count(&count)
+			So(count, ShouldEqual, 0)
+		})
+
+		Convey("Deleting a category.", t, func() {
+			So(do(CategoryController.Delete, "DELETE", "/api/categories/:id?reassign=1234", restaurant.ID, ``), ShouldEqual, http.StatusBadRequest)
+			So(do(CategoryController.Delete, "DELETE", "/api/categories/:id", restaurant.ID, ``), ShouldEqual, http.StatusOK)
+
+			count := 0
+			db.DB.Model(&models.Expenditure{}).Where("category_id = 0").Count(&count)
+			So(count, ShouldEqual, 1)
+
+			So(do(CategoryController.Delete, "DELETE", "/api/categories/:id?reassign="+strconv.Itoa(int(food.ID)), food.ID, ``), ShouldEqual, http.StatusBadRequest)
+			So(do(CategoryController.Create, "POST", "/api/categories", 0, `{"name": "Restaurant"}`), ShouldEqual, http.StatusCreated)
+		})
+	})
+}
+
+func TestCategoryControllerArchived(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		old := &models.Category{Name: "Old", SortOrder: 1}
+		groceries := &models.Category{Name: "Groceries", SortOrder: 2}
+		car := &models.Category{Name: "Car", SortOrder: 2}
+		db.DB.Create(old)
+		db.DB.Create(groceries)
+		db.DB.Create(car)
+		db.DB.Create(&models.Expenditure{Amount: 10, Date: time.Date(2012, 1, 1, 12, 0, 0, 0, time.Local), Category: old})
+
+		index := func(target string) []*CategoryResponse {
+			r := httptest.NewRequest("GET", target, nil)
+			w := httptest.NewRecorder()
+			So(CategoryController.Index(e.NewContext(r, w)), ShouldBeNil)
+
+			answer := &struct {
+				Data []*CategoryResponse `json:"data"`
+			}{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			return answer.Data
+		}
+
+		Convey("Updating the category metadata.", t, func() {
+			r := httptest.NewRequest("POST", "/api/categories/:id", strings.NewReader(`{"archived": true, "color": "#aa00FF", "icon": "history"}`))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			c := e.NewContext(r, w)
+			c.SetParamNames("id")
+			c.SetParamValues(strconv.Itoa(int(old.ID)))
+			So(CategoryController.Update(c), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			answer := &CategoryResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(answer.Name, ShouldEqual, "Old")
+			So(answer.Archived, ShouldBeTrue)
+			So(answer.Color, ShouldEqual, "#aa00FF")
+
+			r = httptest.NewRequest("POST", "/api/categories", strings.NewReader(`{"name": "New", "color": "red"}`))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w = httptest.NewRecorder()
+			So(CategoryController.Create(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Archived categories are hidden from the list.", t, func() {
+			data := index("/api/categories")
+			So(len(data), ShouldEqual, 2)
+			So(data[0].Name, ShouldEqual, "Car")
+			So(data[1].Name, ShouldEqual, "Groceries")
+
+			data = index("/api/categories?archived=true")
+			So(len(data), ShouldEqual, 3)
+			So(data[0].Name, ShouldEqual, "Old")
+		})
+
+		Convey("Archived categories still show in the statistics.", t, func() {
+			r := httptest.NewRequest("GET", "/api/stats/categories", nil)
+			w := httptest.NewRecorder()
+			So(CategoryStatsController.Index(e.NewContext(r, w)), ShouldBeNil)
+
+			stats := []*CategoryStatsResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(&stats), ShouldBeNil)
+			So(len(stats), ShouldEqual, 1)
+			So(stats[0].Name.String, ShouldEqual, "Old")
+		})
+	})
+}
@@ -0,0 +1,318 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+// CategoryRuleMatchResponse holds an expenditure and the rule that categorizes it.
+type CategoryRuleMatchResponse struct {
+	Expenditure *ExpenditureResponse  `json:"expenditure"`
+	Rule        *CategoryRuleResponse `json:"rule"`
+}
+
+// categoryRuleParams holds the fields used to create or update a rule.
+type categoryRuleParams struct {
+	Name      string           `json:"name" form:"name"`
+	Priority  int              `json:"priority" form:"priority"`
+	Category  string           `json:"category" form:"category"`
+	Contains  string           `json:"contains" form:"contains"`
+	Pattern   string           `json:"pattern" form:"pattern"`
+	MinAmount float64          `json:"min_amount" form:"min_amount"`
+	MaxAmount float64          `json:"max_amount" form:"max_amount"`
+	Direction models.Direction `json:"direction" form:"direction"`
+	Account   uint             `json:"account" form:"account"`
+	Weekdays  []int            `json:"weekdays" form:"weekdays"`
+}
+
+// ruleParamsFrom returns the params of an existing rule so an update only has to send what changes.
+func ruleParamsFrom(rule *models.CategoryRule) *categoryRuleParams {
+	params := &categoryRuleParams{
+		Name:      rule.Name,
+		Priority:  rule.Priority,
+		Contains:  rule.Contains,
+		Pattern:   rule.Pattern,
+		MinAmount: rule.MinAmount,
+		MaxAmount: rule.MaxAmount,
+		Direction: rule.Direction,
+		Account:   rule.AccountID,
+		Weekdays:  []int{},
+	}
+
+	if rule.Category != nil {
+		params.Category = rule.Category.Name
+	}
+
+	for _, day := range rule.WeekdayList() {
+		params.Weekdays = append(params.Weekdays, int(day))
+	}
+
+	return params
+}
+
+// saveCategoryRule validates params, copies them into rule and saves it.
+// The returned status is http.StatusOK when everything went fine.
+func (c *categoryRuleController) saveCategoryRule(action string, rule *models.CategoryRule, params *categoryRuleParams) int {
+	params.Category = strings.TrimSpace(params.Category)
+	if len(params.Category) == 0 {
+		log.Infof("CategoryRuleController::%s Category cant be empty.", action)
+		return http.StatusBadRequest
+	}
+
+	weekdays, err := weekdaysFromInts(params.Weekdays)
+	if err != nil {
+		log.Infof("CategoryRuleController::%s %v.", action, err)
+		return http.StatusBadRequest
+	}
+
+	if params.Account != 0 {
+		if _, err := findAccount(params.Account); err != nil {
+			log.Infof("CategoryRuleController::%s Could not find account '%d': '%v'.", action, params.Account, err)
+			return http.StatusBadRequest
+		}
+	}
+
+	rule.Name = strings.TrimSpace(params.Name)
+	rule.Priority = params.Priority
+	rule.Contains = params.Contains
+	rule.Pattern = params.Pattern
+	rule.MinAmount = params.MinAmount
+	rule.MaxAmount = params.MaxAmount
+	rule.Direction = params.Direction
+	rule.AccountID = params.Account
+	rule.SetWeekdays(weekdays...)
+
+	if _, err := prepareCategoryRule(rule); err != nil {
+		log.Infof("CategoryRuleController::%s Invalid rule: '%v'.", action, err)
+		return http.StatusBadRequest
+	}
+
+	category := &models.Category{Name: params.Category}
+	if q := db.DB.FirstOrCreate(category, "name = ?", category.Name); q.Error != nil {
+		log.Errorf("CategoryRuleController::%s FirstOrCreate failed: '%v'.", action, q.Error)
+		return http.StatusInternalServerError
+	}
+	rule.Category = category
+	rule.CategoryID = category.ID
+
+	if q := db.DB.Save(rule); q.Error != nil {
+		log.Errorf("CategoryRuleController::%s Save failed: '%v'.", action, q.Error)
+		return http.StatusInternalServerError
+	}
+
+	return http.StatusOK
+}
+
+type categoryRuleController struct {
+}
+
+func (c *categoryRuleController) Index(ctx echo.Context) error {
+	rules := []*models.CategoryRule{}
+
+	if q := db.DB.Preload("Category").Order("priority desc, id asc").Find(&rules); q.Error != nil {
+		log.Errorf("CategoryRuleController::Index Could not execute find query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("CategoryRuleController::Index Returning %d rules.", len(rules))
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data": TransformCategoryRule(rules...),
+	})
+}
+
+func (c *categoryRuleController) Create(ctx echo.Context) error {
+	params := &categoryRuleParams{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("CategoryRuleController::Create Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	rule := &models.CategoryRule{}
+	if status := c.saveCategoryRule("Create", rule, params); status != http.StatusOK {
+		return ctx.NoContent(status)
+	}
+
+	log.Infof("CategoryRuleController::Create Rule created: %+v.", rule)
+	return ctx.JSON(http.StatusCreated, TransformCategoryRule(rule)[0])
+}
+
+func (c *categoryRuleController) Update(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("CategoryRuleController::Update Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	rule := &models.CategoryRule{}
+	if q := db.DB.Preload("Category").First(rule, "id = ?", id); q.Error != nil {
+		if q.RecordNotFound() {
+			log.Infof("CategoryRuleController::Update Rule '%d' not found.", id)
+			return ctx.NoContent(http.StatusNotFound)
+		}
+
+		log.Errorf("CategoryRuleController::Update First failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	// Fields that are not sent keep their current value.
+	params := ruleParamsFrom(rule)
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("CategoryRuleController::Update Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if status := c.saveCategoryRule("Update", rule, params); status != http.StatusOK {
+		return ctx.NoContent(status)
+	}
+
+	log.Infof("CategoryRuleController::Update Updated: %+v.", rule)
+	return ctx.JSON(http.StatusOK, TransformCategoryRule(rule)[0])
+}
+
+func (c *categoryRuleController) Delete(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("CategoryRuleController::Delete Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	q := db.DB.Where("id = ?", id).Delete(&models.CategoryRule{})
+	if q.Error != nil {
+		log.Errorf("CategoryRuleController::Delete Delete failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	if q.RowsAffected == 0 {
+		log.Infof("CategoryRuleController::Delete Could not delete rule `%d`. Does not exist.", id)
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	log.Infof("CategoryRuleController::Delete Rule '%d' deleted.", id)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Test returns the rule that would categorize the given expenditure, without storing anything.
+func (c *categoryRuleController) Test(ctx echo.Context) error {
+	params := &struct {
+		Date        time.Time        `json:"date" form:"date"`
+		Amount      float64          `json:"amount" form:"amount"`
+		Direction   models.Direction `json:"direction" form:"direction"`
+		Account     uint             `json:"account" form:"account"`
+		Description string           `json:"description" form:"description"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("CategoryRuleController::Test Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if params.Direction == "" {
+		params.Direction = models.DirectionExpense
+	}
+
+	account, err := findAccount(params.Account)
+	if err != nil {
+		log.Infof("CategoryRuleController::Test Could not find account '%d': '%v'.", params.Account, err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	expenditure := &models.Expenditure{
+		Amount:      params.Amount,
+		Date:        params.Date,
+		Direction:   params.Direction,
+		Description: strings.TrimSpace(params.Description),
+		Account:     account,
+		AccountID:   account.ID,
+	}
+
+	rule, err := categorize(expenditure)
+	if err != nil {
+		log.Errorf("CategoryRuleController::Test Could not apply rules: '%v'.", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	result := &CategoryRuleMatchResponse{Expenditure: TransformExpenditure(expenditure)[0]}
+	if rule != nil {
+		result.Rule = TransformCategoryRule(rule)[0]
+	}
+
+	log.Infof("CategoryRuleController::Test Matched rule: %+v.", rule)
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// Apply categorizes the existing expenditures without a category.
+// With dry_run set, it only returns what would change.
+func (c *categoryRuleController) Apply(ctx echo.Context) error {
+	params := &struct {
+		DryRun bool `json:"dry_run" form:"dry_run"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("CategoryRuleController::Apply Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	rules, err := loadCategoryRules()
+	if err != nil {
+		log.Errorf("CategoryRuleController::Apply Could not load rules: '%v'.", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	expenditures := []*models.Expenditure{}
+	q := db.DB.Preload("Account").Where("category_id = 0 OR category_id IS NULL").Order("date, id")
+	q = q.Where("id NOT IN (SELECT expenditure_id FROM expenditure_splits WHERE deleted_at IS NULL)")
+	if q = q.Find(&expenditures); q.Error != nil {
+		log.Errorf("CategoryRuleController::Apply Could not execute find query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	tx := db.DB.Begin()
+	result := []*CategoryRuleMatchResponse{}
+	for _, expenditure := range expenditures {
+		rule := matchCategoryRule(rules, expenditure)
+		if rule == nil {
+			continue
+		}
+
+		expenditure.Category = rule.Category
+		expenditure.CategoryID = rule.CategoryID
+		result = append(result, &CategoryRuleMatchResponse{
+			Expenditure: TransformExpenditure(expenditure)[0],
+			Rule:        TransformCategoryRule(rule.CategoryRule)[0],
+		})
+
+		if params.DryRun {
+			continue
+		}
+
+		if q := tx.Model(&models.Expenditure{}).Where("id = ?", expenditure.ID).UpdateColumn("category_id", rule.CategoryID); q.Error != nil {
+			tx.Rollback()
+			log.Errorf("CategoryRuleController::Apply Update failed: '%v'.", q.Error)
+			return ctx.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	if params.DryRun {
+		tx.Rollback()
+	} else if q := tx.Commit(); q.Error != nil {
+		log.Errorf("CategoryRuleController::Apply Commit failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.WithFields(log.Fields{"dry_run": params.DryRun, "categorized": len(result)}).Infof("CategoryRuleController::Apply Applied rules.")
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data":    result,
+		"dry_run": params.DryRun,
+	})
+}
+
+// CategoryRuleController for /rules endpoint.
+var CategoryRuleController categoryRuleController
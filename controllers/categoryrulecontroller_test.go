@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCategoryRules(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		// 2017-03-04 is a saturday.
+		saturday := time.Date(2017, 3, 4, 12, 0, 0, 0, time.Local)
+
+		post := func(endpoint echo.HandlerFunc, body string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest("POST", "/api/rules", strings.NewReader(body))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			So(endpoint(e.NewContext(r, w)), ShouldBeNil)
+			return w
+		}
+
+		uncategorized := &models.Expenditure{Direction: models.DirectionExpense, Amount: 80, Date: saturday, Description: "SHELL 1234"}
+		db.DB.Create(uncategorized)
+
+		Convey("Creating rules.", t, func() {
+			w := post(CategoryRuleController.Create, `{"category": "groceries", "contains": "colruyt"}`)
+			So(w.Code, ShouldEqual, http.StatusCreated)
+
+			w = post(CategoryRuleController.Create, `{"category": "going out", "contains": "colruyt", "weekdays": [0, 6], "priority": 10}`)
+			So(w.Code, ShouldEqual, http.StatusCreated)
+
+			w = post(CategoryRuleController.Create, `{"category": "car", "pattern": "^(SHELL|ESSO) ", "max_amount": 100}`)
+			So(w.Code, ShouldEqual, http.StatusCreated)
+
+			w = post(CategoryRuleController.Create, `{"category": "car", "pattern": "("}`)
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Rules categorize new expenditures without a category.", t, func() {
+			expenditure, _, err := createExpenditure(models.DirectionExpense, &expenditureParams{Date: saturday, Amount: 20, Description: "Colruyt Gent"})
+			So(err, ShouldBeNil)
+			So(expenditure.Category.Name, ShouldEqual, "going out")
+
+			expenditure, _, err = createExpenditure(models.DirectionExpense, &expenditureParams{Date: saturday.AddDate(0, 0, 2), Amount: 30, Description: "Colruyt Gent"})
+			So(err, ShouldBeNil)
+			So(expenditure.Category.Name, ShouldEqual, "groceries")
+
+			expenditure, _, err = createExpenditure(models.DirectionExpense, &expenditureParams{Date: saturday, Amount: 40, Category: "gifts", Description: "Colruyt Gent"})
+			So(err, ShouldBeNil)
+			So(expenditure.Category.Name, ShouldEqual, "gifts")
+		})
+
+		Convey("Applying rules to existing expenditures.", t, func() {
+			w := post(CategoryRuleController.Apply, `{"dry_run": true}`)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			answer := &struct {
+				Data []*CategoryRuleMatchResponse `json:"data"`
+			}{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(len(answer.Data), ShouldEqual, 1)
+			So(answer.Data[0].Expenditure.ID, ShouldEqual, uncategorized.ID)
+			So(answer.Data[0].Expenditure.Category.Name, ShouldEqual, "car")
+
+			db.DB.First(uncategorized, uncategorized.ID)
+			So(uncategorized.CategoryID, ShouldEqual, 0)
+
+			w = post(CategoryRuleController.Apply, `{}`)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			db.DB.First(uncategorized, uncategorized.ID)
+			So(uncategorized.CategoryID, ShouldNotEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+)
+
+// categoryRule is a rule with its conditions prepared for matching.
+type categoryRule struct {
+	*models.CategoryRule
+
+	contains string
+	pattern  *regexp.Regexp
+}
+
+// prepareCategoryRule validates rule and prepares it for matching.
+func prepareCategoryRule(rule *models.CategoryRule) (*categoryRule, error) {
+	prepared := &categoryRule{
+		CategoryRule: rule,
+		contains:     strings.ToLower(strings.TrimSpace(rule.Contains)),
+	}
+
+	if rule.Pattern != "" {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %v", err)
+		}
+		prepared.pattern = pattern
+	}
+
+	if rule.MinAmount < 0 || rule.MaxAmount < 0 {
+		return nil, errors.New("amounts cant be negative")
+	}
+	if rule.MaxAmount != 0 && rule.MinAmount > rule.MaxAmount {
+		return nil, errors.New("minimum amount is larger than maximum amount")
+	}
+
+	if rule.Direction != "" && rule.Direction != models.DirectionExpense && rule.Direction != models.DirectionIncome {
+		return nil, fmt.Errorf("unknown direction `%s`", rule.Direction)
+	}
+
+	if rule.Weekdays >= 1<<7 {
+		return nil, errors.New("invalid weekdays")
+	}
+
+	return prepared, nil
+}
+
+// matches returns whether all conditions of the rule match e.
+func (r *categoryRule) matches(e *models.Expenditure) bool {
+	if r.contains != "" && !strings.Contains(strings.ToLower(e.Description), r.contains) {
+		return false
+	}
+	if r.pattern != nil && !r.pattern.MatchString(e.Description) {
+		return false
+	}
+	if r.MinAmount != 0 && e.Amount < r.MinAmount {
+		return false
+	}
+	if r.MaxAmount != 0 && e.Amount > r.MaxAmount {
+		return false
+	}
+	if r.Direction != "" && r.Direction != e.Direction {
+		return false
+	}
+	if r.AccountID != 0 && r.AccountID != e.AccountID {
+		return false
+	}
+
+	return r.HasWeekday(e.Date.Weekday())
+}
+
+// loadCategoryRules returns all rules, the ones that should be tried first first.
+// Rules that became invalid are left out.
+func loadCategoryRules() ([]*categoryRule, error) {
+	rules := []*models.CategoryRule{}
+	if q := db.DB.Preload("Category").Order("priority desc, id asc").Find(&rules); q.Error != nil {
+		return nil, q.Error
+	}
+
+	prepared := []*categoryRule{}
+	for _, rule := range rules {
+		if p, err := prepareCategoryRule(rule); err == nil {
+			prepared = append(prepared, p)
+		}
+	}
+
+	return prepared, nil
+}
+
+// matchCategoryRule returns the first rule that matches e or nil.
+func matchCategoryRule(rules []*categoryRule, e *models.Expenditure) *categoryRule {
+	for _, rule := range rules {
+		if rule.matches(e) {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// categorize sets the category of e with the rules when it has none.
+// It returns the rule that was used, if any.
+func categorize(e *models.Expenditure) (*models.CategoryRule, error) {
+	if e.Category != nil || e.CategoryID != 0 {
+		return nil, nil
+	}
+
+	rules, err := loadCategoryRules()
+	if err != nil {
+		return nil, err
+	}
+
+	rule := matchCategoryRule(rules, e)
+	if rule == nil {
+		return nil, nil
+	}
+
+	e.Category = rule.Category
+	e.CategoryID = rule.CategoryID
+	return rule.CategoryRule, nil
+}
+
+// weekdaysFromInts converts weekday numbers, 0 is sunday, to time.Weekday values.
+func weekdaysFromInts(values []int) ([]time.Weekday, error) {
+	days := []time.Weekday{}
+	for _, value := range values {
+		if value < int(time.Sunday) || value > int(time.Saturday) {
+			return nil, fmt.Errorf("invalid weekday `%d`", value)
+		}
+		days = append(days, time.Weekday(value))
+	}
+
+	return days, nil
+}
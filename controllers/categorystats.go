@@ -3,14 +3,27 @@ package controllers
 import (
 	"github.com/jinzhu/gorm"
 	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
 )
 
-func categoryStatsQuery() *gorm.DB {
+// categoryAmountsQuery joins the expenditures with their category. Expenditures
+// with splits give one row per split, in the category of that split.
+func categoryAmountsQuery(direction models.Direction) *gorm.DB {
 	q := db.DB.Table("expenditures")
-	q = q.Joins("LEFT JOIN categories ON expenditures.category_id = categories.id")
-	q = q.Group("expenditures.category_id")
+	q = q.Joins("LEFT JOIN expenditure_splits ON expenditure_splits.expenditure_id = expenditures.id AND expenditure_splits.deleted_at IS NULL")
+	q = q.Joins("LEFT JOIN categories ON COALESCE(expenditure_splits.category_id, expenditures.category_id) = categories.id")
 	q = q.Where("expenditures.deleted_at IS NULL")
-	q = q.Select("categories.id as id, categories.name AS name, SUM(expenditures.amount) as total")
+	q = q.Where("expenditures.direction = ?", direction)
+
+	return q
+}
+
+// categoryStatsQuery sums the expenditures per category. Expenditures
+// with splits count each split in the category of that split.
+func categoryStatsQuery(direction models.Direction) *gorm.DB {
+	q := categoryAmountsQuery(direction)
+	q = q.Group("COALESCE(expenditure_splits.category_id, expenditures.category_id)")
+	q = q.Select("categories.id as id, categories.name AS name, SUM(COALESCE(expenditure_splits.amount, expenditures.amount)) as total")
 
 	return q
 }
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -10,56 +12,177 @@ import (
 )
 
 // CategoryStatsResponse contains statistics for a category.
+// Total only counts the category itself, RollupTotal includes its subcategories.
 type CategoryStatsResponse struct {
-	ID    uint              `json:"id"`
-	Name  models.NullString `json:"name"`
-	Total float64           `json:"total"`
+	ID          uint                        `json:"id"`
+	Name        models.NullString           `json:"name"`
+	Parent      *uint                       `json:"parent"`
+	Total       float64                     `json:"total"`
+	RollupTotal float64                     `json:"rollup_total"`
+	Comparison  *CategoryComparisonResponse `json:"comparison,omitempty"`
 }
 
-type categoryStatsController struct {
+// CategoryComparisonResponse holds the totals of a category in the period it is compared with
+// and how much the totals changed since then. The percentages are null when the old total is 0.
+type CategoryComparisonResponse struct {
+	Start            time.Time          `json:"start"`
+	End              time.Time          `json:"end"`
+	Total            float64            `json:"total"`
+	RollupTotal      float64            `json:"rollup_total"`
+	Change           float64            `json:"change"`
+	Percentage       models.NullFloat64 `json:"percentage"`
+	RollupChange     float64            `json:"rollup_change"`
+	RollupPercentage models.NullFloat64 `json:"rollup_percentage"`
 }
 
-func (c *categoryStatsController) Index(ctx echo.Context) error {
+// categoryStats returns the totals per category in [start, end), everything when start is zero.
+func categoryStats(direction models.Direction, start time.Time, end time.Time, categories map[uint]*models.Category) ([]*CategoryStatsResponse, error) {
 	stats := []*CategoryStatsResponse{}
 
-	var start time.Time
-	var end time.Time
+	q := categoryStatsQuery(direction)
+	if !start.IsZero() {
+		q = dateRangeQuery(start, end, q)
+	}
+
+	if q = q.Scan(&stats); q.Error != nil {
+		return nil, q.Error
+	}
+
+	return rollupCategoryStats(stats, categories), nil
+}
+
+// isMonthStart returns whether t is midnight on the first day of a month.
+func isMonthStart(t time.Time) bool {
+	hour, min, sec := t.Clock()
+	return t.Day() == 1 && hour == 0 && min == 0 && sec == 0 && t.Nanosecond() == 0
+}
+
+// parseComparisonRange parses the optional compare query parameter and returns the
+// period [start, end) is compared with: the previous period of the same length,
+// the same period last year or the explicit compare_start and compare_end range.
+// Zero times are returned when there is nothing to compare.
+func parseComparisonRange(ctx echo.Context, start time.Time, end time.Time) (time.Time, time.Time, error) {
+	compare := ctx.QueryParam("compare")
+	if len(compare) == 0 {
+		return time.Time{}, time.Time{}, nil
+	}
+
+	if start.IsZero() {
+		return start, end, errors.New("compare needs a start and end")
+	}
+
+	switch compare {
+	case "previous":
+		// Whole months are compared with the same number of months before them,
+		// otherwise february would be compared with a part of january.
+		if isMonthStart(start) && isMonthStart(end) {
+			months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+			return start.AddDate(0, -months, 0), start, nil
+		}
 
-	if startQ := ctx.QueryParam("start"); len(startQ) > 0 {
+		return start.Add(-end.Sub(start)), start, nil
+	case "last_year":
+		return start.AddDate(-1, 0, 0), end.AddDate(-1, 0, 0), nil
+	case "range":
+		var compareStart, compareEnd time.Time
 		var err error
-		start, err = time.Parse(time.RFC3339, startQ)
-		if err != nil {
-			log.Infof("CategoryStatsController::Index Failed to parse start `%s`: %v", startQ, err)
-			return ctx.NoContent(http.StatusBadRequest)
+		if compareStart, err = time.Parse(time.RFC3339, ctx.QueryParam("compare_start")); err != nil {
+			return compareStart, compareEnd, fmt.Errorf("failed to parse compare_start `%s`: %v", ctx.QueryParam("compare_start"), err)
+		}
+		if compareEnd, err = time.Parse(time.RFC3339, ctx.QueryParam("compare_end")); err != nil {
+			return compareStart, compareEnd, fmt.Errorf("failed to parse compare_end `%s`: %v", ctx.QueryParam("compare_end"), err)
+		}
+		if !compareStart.Before(compareEnd) {
+			return compareStart, compareEnd, errors.New("compare_start should be before compare_end")
 		}
+
+		return compareStart, compareEnd, nil
 	}
 
-	if endQ := ctx.QueryParam("end"); len(endQ) > 0 {
-		var err error
-		end, err = time.Parse(time.RFC3339, endQ)
-		if err != nil {
-			log.Infof("CategoryStatsController::Index Failed to parse end `%s`: %v", endQ, err)
-			return ctx.NoContent(http.StatusBadRequest)
+	return time.Time{}, time.Time{}, fmt.Errorf("unknown compare `%s`", compare)
+}
+
+// compareCategoryStats adds the totals of previous to stats. Categories that only
+// have expenditures in the previous period are added with a total of 0.
+func compareCategoryStats(stats []*CategoryStatsResponse, previous []*CategoryStatsResponse, start time.Time, end time.Time) []*CategoryStatsResponse {
+	byID := map[uint]*CategoryStatsResponse{}
+	for _, stat := range stats {
+		byID[stat.ID] = stat
+		stat.Comparison = &CategoryComparisonResponse{Start: start, End: end}
+	}
+
+	for _, old := range previous {
+		stat, ok := byID[old.ID]
+		if !ok {
+			stat = &CategoryStatsResponse{ID: old.ID, Name: old.Name, Parent: old.Parent}
+			stat.Comparison = &CategoryComparisonResponse{Start: start, End: end}
+			byID[old.ID] = stat
+			stats = append(stats, stat)
 		}
+
+		stat.Comparison.Total = old.Total
+		stat.Comparison.RollupTotal = old.RollupTotal
 	}
 
-	if start.IsZero() != end.IsZero() {
-		log.Infof("CategoryStatsController::Index Start and end should both be given.")
+	for _, stat := range stats {
+		comparison := stat.Comparison
+		comparison.Change = stat.Total - comparison.Total
+		comparison.RollupChange = stat.RollupTotal - comparison.RollupTotal
+		if comparison.Total != 0 {
+			comparison.Percentage.Set(comparison.Change / comparison.Total * 100)
+		}
+		if comparison.RollupTotal != 0 {
+			comparison.RollupPercentage.Set(comparison.RollupChange / comparison.RollupTotal * 100)
+		}
+	}
+
+	return stats
+}
+
+type categoryStatsController struct {
+}
+
+func (c *categoryStatsController) Index(ctx echo.Context) error {
+	start, end, err := parseDateRange(ctx)
+	if err != nil {
+		log.Infof("CategoryStatsController::Index %v", err)
 		return ctx.NoContent(http.StatusBadRequest)
 	}
 
-	q := categoryStatsQuery()
-	if !start.IsZero() {
-		q = dateRangeQuery(start, end, q)
+	direction, err := parseDirectionParam(ctx)
+	if err != nil {
+		log.Infof("CategoryStatsController::Index %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
 	}
 
-	q = q.Scan(&stats)
+	compareStart, compareEnd, err := parseComparisonRange(ctx, start, end)
+	if err != nil {
+		log.Infof("CategoryStatsController::Index %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	categories, err := loadCategories()
+	if err != nil {
+		log.Errorf("CategoryStatsController::Index Could not load categories: %v", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
 
-	if q.Error != nil {
-		log.Infof("CategoryStatsController::Index Could not execute query: %v", q.Error)
+	stats, err := categoryStats(direction, start, end, categories)
+	if err != nil {
+		log.Infof("CategoryStatsController::Index Could not execute query: %v", err)
 		return ctx.NoContent(http.StatusInternalServerError)
 	}
 
+	if !compareStart.IsZero() {
+		previous, err := categoryStats(direction, compareStart, compareEnd, categories)
+		if err != nil {
+			log.Infof("CategoryStatsController::Index Could not execute query: %v", err)
+			return ctx.NoContent(http.StatusInternalServerError)
+		}
+
+		stats = compareCategoryStats(stats, previous, compareStart, compareEnd)
+	}
+
 	logFields := log.Fields{
 		"results": len(stats),
 	}
@@ -67,6 +190,10 @@ func (c *categoryStatsController) Index(ctx echo.Context) error {
 		logFields["start"] = start
 		logFields["end"] = end
 	}
+	if !compareStart.IsZero() {
+		logFields["compare_start"] = compareStart
+		logFields["compare_end"] = compareEnd
+	}
 
 	log.WithFields(logFields).Infof("Returning category statistics.")
 	return ctx.JSON(http.StatusOK, stats)
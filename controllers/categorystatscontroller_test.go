@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCategoryStatsComparison(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		create := func(date time.Time, amount float64, category string) {
+			_, _, err := createExpenditure(models.DirectionExpense, &expenditureParams{Date: date, Amount: amount, Category: category})
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		create(time.Date(2016, 3, 10, 12, 0, 0, 0, time.UTC), 50, "food")
+		create(time.Date(2017, 2, 10, 12, 0, 0, 0, time.UTC), 200, "food")
+		create(time.Date(2017, 2, 20, 12, 0, 0, 0, time.UTC), 40, "books")
+		create(time.Date(2017, 3, 10, 12, 0, 0, 0, time.UTC), 250, "food")
+
+		index := func(url string) (map[string]*CategoryStatsResponse, int) {
+			r := httptest.NewRequest("GET", url, nil)
+			w := httptest.NewRecorder()
+			So(CategoryStatsController.Index(e.NewContext(r, w)), ShouldBeNil)
+
+			stats := []*CategoryStatsResponse{}
+			if w.Code == http.StatusOK {
+				So(json.NewDecoder(w.Result().Body).Decode(&stats), ShouldBeNil)
+			}
+
+			byName := map[string]*CategoryStatsResponse{}
+			for _, stat := range stats {
+				byName[stat.Name.String] = stat
+			}
+			return byName, w.Code
+		}
+
+		Convey("Comparing with the previous month.", t, func() {
+			stats, code := index("/api/stats/categories?start=2017-03-01T00:00:00Z&end=2017-04-01T00:00:00Z&compare=previous")
+			So(code, ShouldEqual, http.StatusOK)
+			So(len(stats), ShouldEqual, 2)
+
+			food := stats["food"].Comparison
+			So(food.Start.Equal(time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)), ShouldBeTrue)
+			So(food.Total, ShouldEqual, 200)
+			So(food.Change, ShouldEqual, 50)
+			So(food.Percentage.Float64, ShouldEqual, 25)
+
+			books := stats["books"]
+			So(books.Total, ShouldEqual, 0)
+			So(books.Comparison.Change, ShouldEqual, -40)
+			So(books.Comparison.Percentage.Float64, ShouldEqual, -100)
+		})
+
+		Convey("Comparing with last year.", t, func() {
+			stats, code := index("/api/stats/categories?start=2017-03-01T00:00:00Z&end=2017-04-01T00:00:00Z&compare=last_year")
+			So(code, ShouldEqual, http.StatusOK)
+			So(len(stats), ShouldEqual, 1)
+			So(stats["food"].Comparison.Total, ShouldEqual, 50)
+			So(stats["food"].Comparison.Change, ShouldEqual, 200)
+		})
+
+		Convey("Comparing with an explicit range.", t, func() {
+			stats, code := index("/api/stats/categories?start=2017-03-01T00:00:00Z&end=2017-04-01T00:00:00Z&compare=range&compare_start=2016-01-01T00:00:00Z&compare_end=2017-01-01T00:00:00Z")
+			So(code, ShouldEqual, http.StatusOK)
+			So(stats["food"].Comparison.Total, ShouldEqual, 50)
+
+			stats, code = index("/api/stats/categories?start=2017-03-01T00:00:00Z&end=2017-04-01T00:00:00Z")
+			So(code, ShouldEqual, http.StatusOK)
+			So(stats["food"].Comparison, ShouldBeNil)
+		})
+
+		Convey("Invalid comparisons are rejected.", t, func() {
+			for _, url := range []string{
+				"/api/stats/categories?compare=previous",
+				"/api/stats/categories?start=2017-03-01T00:00:00Z&end=2017-04-01T00:00:00Z&compare=yesterday",
+				"/api/stats/categories?start=2017-03-01T00:00:00Z&end=2017-04-01T00:00:00Z&compare=range",
+				"/api/stats/categories?start=2017-03-01T00:00:00Z&end=2017-04-01T00:00:00Z&compare=range&compare_start=2017-01-01T00:00:00Z&compare_end=2016-01-01T00:00:00Z",
+				"/api/stats/categories?start=2017-03-01T00:00:00Z&end=2017-04-01T00:00:00Z&compare=range&compare_start=2017-01-01T00:00:00Z&compare_end=2017-01-01T00:00:00Z",
+			} {
+				_, code := index(url)
+				So(code, ShouldEqual, http.StatusBadRequest)
+			}
+		})
+	})
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+)
+
+var (
+	// errCategoryParentNotFound is returned when a category refers to an unknown parent.
+	errCategoryParentNotFound = errors.New("parent category not found")
+	// errCategoryCycle is returned when a category would become its own ancestor.
+	errCategoryCycle = errors.New("category can not be its own ancestor")
+)
+
+// categoryColorPattern matches the colors a category can have.
+var categoryColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// validCategoryColor returns whether color is empty or a hex color like "#ff8800".
+func validCategoryColor(color string) bool {
+	return color == "" || categoryColorPattern.MatchString(color)
+}
+
+// sortCategories orders categories by their sort order and then by name.
+func sortCategories(categories []*models.Category) {
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].SortOrder != categories[j].SortOrder {
+			return categories[i].SortOrder < categories[j].SortOrder
+		}
+		return categories[i].Name < categories[j].Name
+	})
+}
+
+// loadCategories returns all categories by id.
+func loadCategories() (map[uint]*models.Category, error) {
+	categories := []*models.Category{}
+	if q := db.DB.Find(&categories); q.Error != nil {
+		return nil, q.Error
+	}
+
+	byID := map[uint]*models.Category{}
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	return byID, nil
+}
+
+// checkCategoryParent returns whether category id can be moved under parentID.
+func checkCategoryParent(categories map[uint]*models.Category, id uint, parentID uint) error {
+	for current := parentID; current != 0; {
+		if current == id {
+			return errCategoryCycle
+		}
+
+		parent, ok := categories[current]
+		if !ok {
+			return errCategoryParentNotFound
+		}
+		current = parent.ParentID
+	}
+
+	return nil
+}
+
+// categoryAncestors returns the parent, grandparent, ... of category id.
+// It stops at missing categories and cycles.
+func categoryAncestors(categories map[uint]*models.Category, id uint) []uint {
+	ancestors := []uint{}
+	seen := map[uint]bool{id: true}
+
+	category, ok := categories[id]
+	for ok && category.ParentID != 0 && !seen[category.ParentID] {
+		seen[category.ParentID] = true
+		ancestors = append(ancestors, category.ParentID)
+		category, ok = categories[category.ParentID]
+	}
+
+	return ancestors
+}
+
+// buildCategoryTree nests the categories under their parents, sorted like sortCategories.
+// Categories with a missing parent are shown at the top level.
+func buildCategoryTree(categories map[uint]*models.Category) []*CategoryResponse {
+	responses := map[uint]*CategoryResponse{}
+	for id, category := range categories {
+		responses[id] = TransformCategory(category)[0]
+	}
+
+	roots := []*CategoryResponse{}
+	for id, category := range categories {
+		parent, ok := responses[category.ParentID]
+		if category.ParentID == 0 || !ok {
+			roots = append(roots, responses[id])
+			continue
+		}
+		parent.Children = append(parent.Children, responses[id])
+	}
+
+	var sortTree func([]*CategoryResponse)
+	sortTree = func(nodes []*CategoryResponse) {
+		sort.Slice(nodes, func(i, j int) bool {
+			if nodes[i].SortOrder != nodes[j].SortOrder {
+				return nodes[i].SortOrder < nodes[j].SortOrder
+			}
+			return nodes[i].Name < nodes[j].Name
+		})
+		for _, node := range nodes {
+			sortTree(node.Children)
+		}
+	}
+	sortTree(roots)
+
+	return roots
+}
+
+// rollupCategoryStats adds the totals of subcategories to their ancestors.
+// Ancestors without expenditures of their own are added with a total of 0.
+func rollupCategoryStats(stats []*CategoryStatsResponse, categories map[uint]*models.Category) []*CategoryStatsResponse {
+	byID := map[uint]*CategoryStatsResponse{}
+	for _, stat := range stats {
+		stat.RollupTotal = stat.Total
+		if stat.ID != 0 {
+			byID[stat.ID] = stat
+		}
+	}
+
+	for _, stat := range stats {
+		if stat.ID == 0 {
+			continue
+		}
+
+		for _, ancestor := range categoryAncestors(categories, stat.ID) {
+			parent, ok := byID[ancestor]
+			if !ok {
+				parent = &CategoryStatsResponse{ID: ancestor}
+				parent.Name.Set(categories[ancestor].Name)
+				byID[ancestor] = parent
+				stats = append(stats, parent)
+			}
+			parent.RollupTotal += stat.Total
+		}
+	}
+
+	for _, stat := range stats {
+		if category, ok := categories[stat.ID]; ok && category.ParentID != 0 {
+			parent := category.ParentID
+			stat.Parent = &parent
+		}
+	}
+
+	return stats
+}
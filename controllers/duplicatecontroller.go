@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+// findDuplicateCandidate loads a candidate with both expenditures. Either
+// expenditure is nil when it was deleted in the meantime.
+func findDuplicateCandidate(id uint) (*models.DuplicateCandidate, error) {
+	candidate := &models.DuplicateCandidate{}
+	if q := db.DB.Where("id = ?", id).First(candidate); q.Error != nil {
+		return nil, q.Error
+	}
+
+	if err := loadDuplicatePair(candidate); err != nil {
+		return nil, err
+	}
+
+	return candidate, nil
+}
+
+func loadDuplicatePair(candidate *models.DuplicateCandidate) error {
+	expenditures := []*models.Expenditure{}
+	q := preloadExpenditures(db.DB).Where("id IN (?)", []uint{candidate.ExpenditureID, candidate.DuplicateID}).Find(&expenditures)
+	if q.Error != nil {
+		return q.Error
+	}
+
+	for _, expenditure := range expenditures {
+		switch expenditure.ID {
+		case candidate.ExpenditureID:
+			candidate.Expenditure = expenditure
+		case candidate.DuplicateID:
+			candidate.Duplicate = expenditure
+		}
+	}
+
+	return nil
+}
+
+type duplicateController struct {
+}
+
+// Index lists the candidates that still need to be reviewed.
+// Pairs of which one expenditure was deleted are left out.
+func (c *duplicateController) Index(ctx echo.Context) error {
+	candidates := []*models.DuplicateCandidate{}
+
+	q := db.DB.Where("resolution = ? OR resolution IS NULL", "").Order("score desc, id")
+	if q = q.Find(&candidates); q.Error != nil {
+		log.Errorf("DuplicateController::Index Could not execute find query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	result := []*models.DuplicateCandidate{}
+	for _, candidate := range candidates {
+		if err := loadDuplicatePair(candidate); err != nil {
+			log.Errorf("DuplicateController::Index Could not load expenditures: %v", err)
+			return ctx.NoContent(http.StatusInternalServerError)
+		}
+
+		if candidate.Expenditure != nil && candidate.Duplicate != nil {
+			result = append(result, candidate)
+		}
+	}
+
+	log.Infof("DuplicateController::Index Returning %d duplicate candidates.", len(result))
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data": TransformDuplicateCandidate(result...),
+	})
+}
+
+// Scan looks for duplicates among the stored expenditures and flags them.
+func (c *duplicateController) Scan(ctx echo.Context) error {
+	expenditures := []*models.Expenditure{}
+	if q := db.DB.Order("date, id").Find(&expenditures); q.Error != nil {
+		log.Errorf("DuplicateController::Scan Could not execute find query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	flagged := 0
+	for i, expenditure := range expenditures {
+		for _, other := range expenditures[i+1:] {
+			if other.Date.Sub(expenditure.Date) > duplicateWindow {
+				break
+			}
+
+			score, reason, ok := compareExpenditures(expenditure, other)
+			if !ok {
+				continue
+			}
+
+			// The duplicate is the one that was added last.
+			original, duplicate := expenditure, other
+			if duplicate.ID < original.ID {
+				original, duplicate = duplicate, original
+			}
+
+			created, err := flagDuplicate(original, duplicate, score, reason)
+			if err != nil {
+				log.Errorf("DuplicateController::Scan Could not flag duplicate: %v", err)
+				return ctx.NoContent(http.StatusInternalServerError)
+			}
+			if created {
+				flagged++
+			}
+		}
+	}
+
+	log.Infof("DuplicateController::Scan Flagged %d new duplicate candidates.", flagged)
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"flagged": flagged,
+	})
+}
+
+// Resolve keeps both expenditures or merges the duplicate into the original one.
+func (c *duplicateController) Resolve(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("DuplicateController::Resolve Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	params := &struct {
+		Action string `json:"action" form:"action"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("DuplicateController::Resolve Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if params.Action != "keep" && params.Action != "merge" {
+		log.Infof("DuplicateController::Resolve Unknown action `%s`.", params.Action)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	candidate, err := findDuplicateCandidate(uint(id))
+	if err != nil {
+		log.Infof("DuplicateController::Resolve Could not find duplicate candidate '%d': '%v'.", id, err)
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	if candidate.Resolution != "" {
+		log.Infof("DuplicateController::Resolve Duplicate candidate '%d' is already resolved.", id)
+		return ctx.NoContent(http.StatusConflict)
+	}
+
+	if candidate.Expenditure == nil || candidate.Duplicate == nil {
+		log.Infof("DuplicateController::Resolve One of the expenditures of duplicate candidate '%d' was deleted.", id)
+		return ctx.NoContent(http.StatusConflict)
+	}
+
+	candidate.Resolution = models.DuplicateKept
+	if params.Action == "merge" {
+		if err := mergeExpenditure(candidate.Expenditure, candidate.Duplicate); err != nil {
+			log.Errorf("DuplicateController::Resolve Merge failed: '%v'.", err)
+			return ctx.NoContent(http.StatusInternalServerError)
+		}
+		candidate.Resolution = models.DuplicateMerged
+	}
+
+	if q := db.DB.Model(&models.DuplicateCandidate{}).Where("id = ?", candidate.ID).Update("resolution", candidate.Resolution); q.Error != nil {
+		log.Errorf("DuplicateController::Resolve Update failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("DuplicateController::Resolve Duplicate candidate '%d' resolved: %s.", id, candidate.Resolution)
+	return ctx.JSON(http.StatusOK, TransformDuplicateCandidate(candidate)[0])
+}
+
+// DuplicateController for /expenditures/duplicates endpoint.
+var DuplicateController duplicateController
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDuplicateDetection(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		date := time.Date(2017, 3, 5, 0, 0, 0, 0, time.Local)
+		existing := &models.Expenditure{Direction: models.DirectionExpense, Amount: 43.2, Date: date, Description: "Colruyt Gent"}
+		db.DB.Create(existing)
+
+		create := func(policy DuplicatePolicy, description string) (*models.Expenditure, bool, error) {
+			return createExpenditure(models.DirectionExpense, &expenditureParams{
+				Date:        date.AddDate(0, 0, 1),
+				Amount:      43.2,
+				Description: description,
+				Duplicates:  policy,
+			})
+		}
+
+		Convey("Comparing expenditures.", t, func() {
+			_, _, ok := compareExpenditures(existing, &models.Expenditure{Direction: models.DirectionExpense, Amount: 43.2, Date: date.AddDate(0, 0, 2), Description: "COLRUYT GENT 1234"})
+			So(ok, ShouldBeTrue)
+
+			_, _, ok = compareExpenditures(existing, &models.Expenditure{Direction: models.DirectionExpense, Amount: 43.2, Date: date.AddDate(0, 0, 5), Description: "Colruyt Gent"})
+			So(ok, ShouldBeFalse)
+
+			_, _, ok = compareExpenditures(existing, &models.Expenditure{Direction: models.DirectionExpense, Amount: 43.2, Date: date, Description: "Delhaize"})
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Rejecting a duplicate.", t, func() {
+			_, _, err := create(DuplicatesReject, "Colruyt")
+			So(err, ShouldHaveSameTypeAs, &duplicateError{})
+			So(err.(*duplicateError).Duplicates[0].ID, ShouldEqual, existing.ID)
+		})
+
+		Convey("Merging a duplicate.", t, func() {
+			expenditure, merged, err := create(DuplicatesMerge, "Colruyt")
+			So(err, ShouldBeNil)
+			So(merged, ShouldBeTrue)
+			So(expenditure.ID, ShouldEqual, existing.ID)
+
+			count := 0
+			db.DB.Model(&models.Expenditure{}).Count(&count)
+			So(count, ShouldEqual, 1)
+		})
+
+		var flagged *models.Expenditure
+		Convey("Flagging a duplicate.", t, func() {
+			var err error
+			flagged, _, err = create(DuplicatesFlag, "Colruyt Gent")
+			So(err, ShouldBeNil)
+
+			r := httptest.NewRequest("GET", "/api/expenditures/duplicates", nil)
+			w := httptest.NewRecorder()
+			So(DuplicateController.Index(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			answer := &struct {
+				Data []*DuplicateCandidateResponse `json:"data"`
+			}{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(len(answer.Data), ShouldEqual, 1)
+			So(answer.Data[0].Expenditure.ID, ShouldEqual, existing.ID)
+			So(answer.Data[0].Duplicate.ID, ShouldEqual, flagged.ID)
+		})
+
+		Convey("Resolving a duplicate by merging.", t, func() {
+			candidate := &models.DuplicateCandidate{}
+			db.DB.First(candidate)
+
+			r := httptest.NewRequest("POST", "/api/expenditures/duplicates/:id", strings.NewReader(`{"action": "merge"}`))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			c := e.NewContext(r, w)
+			c.SetParamNames("id")
+			c.SetParamValues(strconv.Itoa(int(candidate.ID)))
+			So(DuplicateController.Resolve(c), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			count := 0
+			db.DB.Model(&models.Expenditure{}).Where("id = ?", flagged.ID).Count(&count)
+			So(count, ShouldEqual, 0)
+
+			db.DB.First(candidate, candidate.ID)
+			So(candidate.Resolution, ShouldEqual, models.DuplicateMerged)
+		})
+	})
+}
+
+func TestMergeSplits(t *testing.T) {
+	withDb(func() {
+		date := time.Date(2017, 3, 5, 0, 0, 0, 0, time.Local)
+		existing := &models.Expenditure{Direction: models.DirectionExpense, Amount: 100, Date: date}
+		db.DB.Create(existing)
+
+		Convey("Merging a split expenditure into an uncategorized one.", t, func() {
+			expenditure, merged, err := createExpenditure(models.DirectionExpense, &expenditureParams{
+				Date:       date,
+				Amount:     100,
+				Splits:     []*splitParams{{Category: "groceries", Amount: 60}, {Category: "household", Amount: 40}},
+				Duplicates: DuplicatesMerge,
+			})
+			So(err, ShouldBeNil)
+			So(merged, ShouldBeTrue)
+			So(len(expenditure.Splits), ShouldEqual, 2)
+
+			stored := &models.Expenditure{}
+			So(preloadExpenditures(db.DB).First(stored, existing.ID).Error, ShouldBeNil)
+			So(len(stored.Splits), ShouldEqual, 2)
+			So(stored.Splits[0].Category.Name, ShouldEqual, "groceries")
+			So(stored.Splits[1].Amount, ShouldEqual, 40)
+		})
+
+		Convey("Merging two stored expenditures moves the splits.", t, func() {
+			into := &models.Expenditure{Direction: models.DirectionExpense, Amount: 20, Date: date}
+			db.DB.Create(into)
+			from := &models.Expenditure{Direction: models.DirectionExpense, Amount: 20, Date: date, Splits: []*models.ExpenditureSplit{{Amount: 5}, {Amount: 15}}}
+			db.DB.Create(from)
+
+			So(mergeExpenditure(into, from), ShouldBeNil)
+
+			count := 0
+			db.DB.Model(&models.ExpenditureSplit{}).Where("expenditure_id = ?", into.ID).Count(&count)
+			So(count, ShouldEqual, 2)
+		})
+	})
+}
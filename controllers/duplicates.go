@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+)
+
+// DuplicatePolicy decides what happens when a new expenditure likely already exists.
+type DuplicatePolicy string
+
+const (
+	// DuplicatesFlag stores the expenditure and flags it for review.
+	DuplicatesFlag DuplicatePolicy = "flag"
+	// DuplicatesReject does not store the expenditure.
+	DuplicatesReject DuplicatePolicy = "reject"
+	// DuplicatesMerge merges the expenditure into the existing one.
+	DuplicatesMerge DuplicatePolicy = "merge"
+)
+
+// Valid returns whether p is a known policy. The empty policy means DuplicatesFlag.
+func (p DuplicatePolicy) Valid() bool {
+	switch p {
+	case "", DuplicatesFlag, DuplicatesReject, DuplicatesMerge:
+		return true
+	}
+
+	return false
+}
+
+const (
+	// duplicateWindow is how far apart the dates of two duplicates can be.
+	duplicateWindow = 3 * 24 * time.Hour
+	// duplicateMinSimilarity is the minimal similarity of two descriptions.
+	duplicateMinSimilarity = 0.5
+)
+
+// errInvalidDuplicatePolicy is returned when an unknown DuplicatePolicy is used.
+var errInvalidDuplicatePolicy = errors.New("invalid duplicates policy")
+
+// duplicateError is returned when an expenditure is rejected because it likely already exists.
+type duplicateError struct {
+	Duplicates []*models.Expenditure
+}
+
+func (e *duplicateError) Error() string {
+	return fmt.Sprintf("expenditure likely already exists %d times", len(e.Duplicates))
+}
+
+// duplicateMatch is an existing expenditure that looks like a new one.
+type duplicateMatch struct {
+	Expenditure *models.Expenditure
+	Score       float64
+	Reason      string
+}
+
+// descriptionTokens splits a description into lowercase words.
+func descriptionTokens(description string) map[string]bool {
+	tokens := map[string]bool{}
+	for _, token := range strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		tokens[token] = true
+	}
+
+	return tokens
+}
+
+// descriptionSimilarity returns the Jaccard similarity of the words of a and b.
+func descriptionSimilarity(a string, b string) float64 {
+	tokensA := descriptionTokens(a)
+	tokensB := descriptionTokens(b)
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0
+	}
+
+	common := 0
+	for token := range tokensA {
+		if tokensB[token] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(tokensA)+len(tokensB)-common)
+}
+
+// compareExpenditures returns whether a and b are likely the same expenditure.
+func compareExpenditures(a *models.Expenditure, b *models.Expenditure) (score float64, reason string, ok bool) {
+	if a.Reference != "" && a.Reference == b.Reference && a.AccountID == b.AccountID {
+		return 1, "same reference", true
+	}
+
+	if a.Direction != b.Direction || math.Abs(a.Amount-b.Amount) >= 0.005 {
+		return 0, "", false
+	}
+
+	diff := a.Date.Sub(b.Date)
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > duplicateWindow {
+		return 0, "", false
+	}
+
+	// Expenditures entered by hand often have no description, so
+	// amount and date alone are enough to suspect a duplicate.
+	if strings.TrimSpace(a.Description) == "" || strings.TrimSpace(b.Description) == "" {
+		return 0.6, "same amount and date", true
+	}
+
+	similarity := descriptionSimilarity(a.Description, b.Description)
+	if similarity < duplicateMinSimilarity {
+		return 0, "", false
+	}
+
+	return 0.6 + 0.4*similarity, "same amount, date and similar description", true
+}
+
+// findDuplicates returns the stored expenditures that are likely the same as e, best match first.
+func findDuplicates(e *models.Expenditure) ([]*duplicateMatch, error) {
+	candidates := []*models.Expenditure{}
+
+	q := preloadExpenditures(db.DB).Where("id <> ?", e.ID)
+	if e.Reference != "" {
+		q = q.Where("(direction = ? AND amount > ? AND amount < ? AND date >= ? AND date <= ?) OR (reference = ? AND account_id = ?)",
+			e.Direction, e.Amount-0.005, e.Amount+0.005, e.Date.Add(-duplicateWindow), e.Date.Add(duplicateWindow), e.Reference, e.AccountID)
+	} else {
+		q = q.Where("direction = ? AND amount > ? AND amount < ? AND date >= ? AND date <= ?",
+			e.Direction, e.Amount-0.005, e.Amount+0.005, e.Date.Add(-duplicateWindow), e.Date.Add(duplicateWindow))
+	}
+
+	if q = q.Find(&candidates); q.Error != nil {
+		return nil, q.Error
+	}
+
+	matches := []*duplicateMatch{}
+	for _, candidate := range candidates {
+		if score, reason, ok := compareExpenditures(e, candidate); ok {
+			matches = append(matches, &duplicateMatch{Expenditure: candidate, Score: score, Reason: reason})
+		}
+	}
+
+	for i := 1; i < len(matches); i++ {
+		for j := i; j > 0 && matches[j].Score > matches[j-1].Score; j-- {
+			matches[j], matches[j-1] = matches[j-1], matches[j]
+		}
+	}
+
+	return matches, nil
+}
+
+// flagDuplicate records that duplicate is likely the same as expenditure,
+// unless that pair was already recorded.
+func flagDuplicate(expenditure *models.Expenditure, duplicate *models.Expenditure, score float64, reason string) (bool, error) {
+	count := 0
+	q := db.DB.Unscoped().Model(&models.DuplicateCandidate{})
+	q = q.Where("(expenditure_id = ? AND duplicate_id = ?) OR (expenditure_id = ? AND duplicate_id = ?)", expenditure.ID, duplicate.ID, duplicate.ID, expenditure.ID)
+	if q = q.Count(&count); q.Error != nil {
+		return false, q.Error
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	candidate := &models.DuplicateCandidate{
+		ExpenditureID: expenditure.ID,
+		DuplicateID:   duplicate.ID,
+		Score:         score,
+		Reason:        reason,
+	}
+	if q := db.DB.Create(candidate); q.Error != nil {
+		return false, q.Error
+	}
+
+	return true, nil
+}
+
+func hasTag(e *models.Expenditure, tag *models.Tag) bool {
+	for _, t := range e.Tags {
+		if t.ID == tag.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeExpenditure copies the details that into is missing from from and
+// deletes from. Both are expected to be loaded from the database. The splits
+// of from are moved to into when into has none and they add up to its amount.
+func mergeExpenditure(into *models.Expenditure, from *models.Expenditure) error {
+	if strings.TrimSpace(into.Payee) == "" {
+		into.Payee = from.Payee
+	}
+	if strings.TrimSpace(into.Description) == "" {
+		into.Description = from.Description
+	}
+	if strings.TrimSpace(into.Notes) == "" {
+		into.Notes = from.Notes
+	}
+	if into.Reference == "" {
+		into.Reference = from.Reference
+	}
+	if into.CategoryID == 0 {
+		into.CategoryID = from.CategoryID
+		into.Category = from.Category
+	}
+
+	for _, tag := range from.Tags {
+		if !hasTag(into, tag) {
+			into.Tags = append(into.Tags, tag)
+		}
+	}
+
+	splits := []*models.ExpenditureSplit{}
+	if len(into.Splits) == 0 && len(from.Splits) > 0 {
+		sum := 0.0
+		for _, split := range from.Splits {
+			sum += split.Amount
+		}
+		if splitsMatch(sum, into.Amount) {
+			splits = from.Splits
+		}
+	}
+
+	tx := db.DB.Begin()
+	if q := tx.Save(into); q.Error != nil {
+		tx.Rollback()
+		return q.Error
+	}
+	if err := resolveSplitCategories(tx, splits); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, split := range splits {
+		split.ExpenditureID = into.ID
+		if q := tx.Save(split); q.Error != nil {
+			tx.Rollback()
+			return q.Error
+		}
+	}
+	if from.ID != 0 {
+		if q := tx.Delete(from); q.Error != nil {
+			tx.Rollback()
+			return q.Error
+		}
+	}
+
+	if q := tx.Commit(); q.Error != nil {
+		return q.Error
+	}
+
+	if len(splits) > 0 {
+		into.Splits = splits
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -74,7 +76,49 @@ func dateRangeQuery(start time.Time, end time.Time, q *gorm.DB) *gorm.DB {
 	return q.Where("date >= ? AND date < ?", start, end)
 }
 
+// likePattern returns a LIKE pattern that matches value anywhere. The
+// wildcards in value are escaped, so conditions using it need ESCAPE '\'.
+func likePattern(value string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value) + "%"
+}
+
+// parseDateRange parses the optional start and end query parameters.
+// Either both or none have to be given. When none are given, zero times are returned.
+func parseDateRange(ctx echo.Context) (start time.Time, end time.Time, err error) {
+	if startQ := ctx.QueryParam("start"); len(startQ) > 0 {
+		if start, err = time.Parse(time.RFC3339, startQ); err != nil {
+			return start, end, fmt.Errorf("failed to parse start `%s`: %v", startQ, err)
+		}
+	}
+
+	if endQ := ctx.QueryParam("end"); len(endQ) > 0 {
+		if end, err = time.Parse(time.RFC3339, endQ); err != nil {
+			return start, end, fmt.Errorf("failed to parse end `%s`: %v", endQ, err)
+		}
+	}
+
+	if start.IsZero() != end.IsZero() {
+		return start, end, errors.New("start and end should both be given")
+	}
+
+	return start, end, nil
+}
+
+// parseDirectionParam parses the optional direction query parameter, it defaults to expenses.
+func parseDirectionParam(ctx echo.Context) (models.Direction, error) {
+	switch d := models.Direction(ctx.QueryParam("direction")); d {
+	case "", models.DirectionExpense:
+		return models.DirectionExpense, nil
+	case models.DirectionIncome:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unknown direction `%s`", d)
+	}
+}
+
 type expenditureController struct {
+	// direction restricts the controller to either expenses or income.
+	direction models.Direction
 }
 
 func (c *expenditureController) Index(ctx echo.Context) error {
@@ -91,7 +135,32 @@ func (c *expenditureController) Index(ctx echo.Context) error {
 		offset = uint(tmp)
 	}
 
-	q := db.DB.Preload("Category")
+	q := preloadExpenditures(db.DB).Where("direction = ?", c.direction)
+
+	if accountQ := ctx.QueryParam("account"); len(accountQ) > 0 {
+		accountID, err := strconv.ParseUint(accountQ, 10, 64)
+		if err != nil {
+			log.Infof("ExpenditureController::Index Could not parse account `%s`: '%v'.", accountQ, err)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		q = q.Where("account_id = ?", accountID)
+	}
+
+	for _, col := range []string{"payee", "description", "notes"} {
+		if value := strings.TrimSpace(ctx.QueryParam(col)); len(value) > 0 {
+			q = q.Where(col+` LIKE ? ESCAPE '\'`, likePattern(value))
+		}
+	}
+
+	// Expenditures should have all of the given tags.
+	for _, tag := range ctx.QueryParams()["tag"] {
+		q = q.Where("id IN (SELECT expenditure_tags.expenditure_id FROM expenditure_tags JOIN tags ON tags.id = expenditure_tags.tag_id WHERE tags.name = ?)", normalizeTagName(tag))
+	}
+
+	if search := strings.TrimSpace(ctx.QueryParam("search")); len(search) > 0 {
+		pattern := likePattern(search)
+		q = q.Where(`payee LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\' OR notes LIKE ? ESCAPE '\'`, pattern, pattern, pattern)
+	}
 
 	var start time.Time
 	var end time.Time
@@ -121,7 +190,7 @@ func (c *expenditureController) Index(ctx echo.Context) error {
 		}
 	}
 
-	q = sortQuery(parseSortParam(ctx.QueryParam("sort"), "id", "amount", "date"), q)
+	q = sortQuery(parseSortParam(ctx.QueryParam("sort"), "id", "amount", "date", "payee"), q)
 
 	limit, q = limitQuery(limit, q)
 	offset, q = offsetQuery(offset, q)
@@ -147,7 +216,7 @@ func (c *expenditureController) Show(ctx echo.Context) error {
 	}
 
 	expenditure := &models.Expenditure{}
-	q := db.DB.Preload("Category").Where("id = ?", id).First(expenditure)
+	q := preloadExpenditures(db.DB).Where("id = ? AND direction = ?", id, c.direction).First(expenditure)
 	if q.RecordNotFound() {
 		log.Infof("ExpenditureController::Show Expenditure '%d' not found.", id)
 		return ctx.NoContent(http.StatusNotFound)
@@ -157,39 +226,224 @@ func (c *expenditureController) Show(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, TransformExpenditure(expenditure)[0])
 }
 
-func (c *expenditureController) Create(ctx echo.Context) error {
-	expenditure := &models.Expenditure{}
-	params := &struct {
-		Date     time.Time `json:"date" form:"date"`
-		Amount   float64   `json:"amount" form:"amount"`
-		Category string    `json:"category" form:"category"`
-	}{}
+// SuggestCategory returns the categories an expenditure likely belongs to,
+// learned from the categorized expenditures.
+func (c *expenditureController) SuggestCategory(ctx echo.Context) error {
+	expenditure := &models.Expenditure{
+		Direction:   c.direction,
+		Description: ctx.QueryParam("description"),
+	}
 
-	if err := ctx.Bind(params); err != nil {
-		log.Infof("ExpenditureController::Create Could not bind params: '%v'.", err)
-		return ctx.NoContent(http.StatusBadRequest)
+	if amountQ := ctx.QueryParam("amount"); len(amountQ) > 0 {
+		amount, err := strconv.ParseFloat(amountQ, 64)
+		if err != nil {
+			log.Infof("ExpenditureController::SuggestCategory Could not parse amount `%s`: '%v'.", amountQ, err)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		expenditure.Amount = amount
+	}
+
+	if dateQ := ctx.QueryParam("date"); len(dateQ) > 0 {
+		date, err := time.Parse(time.RFC3339, dateQ)
+		if err != nil {
+			log.Infof("ExpenditureController::SuggestCategory Could not parse date `%s`: '%v'.", dateQ, err)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		expenditure.Date = date
+	}
+
+	var limit uint = 5
+	if tmp, err := strconv.ParseUint(ctx.QueryParam("limit"), 10, 64); err == nil {
+		limit = uint(tmp)
+	}
+
+	history := []*models.Expenditure{}
+	q := db.DB.Preload("Category").Where("direction = ? AND category_id > 0", c.direction)
+	if q = q.Find(&history); q.Error != nil {
+		log.Errorf("ExpenditureController::SuggestCategory Failed to execute query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	// Archived categories still teach the classifier about the history, but are not suggested.
+	suggestions := []*categorySuggestion{}
+	for _, suggestion := range trainCategoryClassifier(history).suggest(expenditure) {
+		if !suggestion.Category.Archived {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	if uint(len(suggestions)) > limit {
+		suggestions = suggestions[:limit]
 	}
 
+	log.WithFields(log.Fields{"history": len(history), "size": len(suggestions)}).Infof("ExpenditureController::SuggestCategory Returning suggestions.")
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data": TransformCategorySuggestion(suggestions...),
+	})
+}
+
+// expenditureParams holds the fields used to create an expenditure.
+type expenditureParams struct {
+	Date        time.Time `json:"date" form:"date"`
+	Amount      float64   `json:"amount" form:"amount"`
+	Category    string    `json:"category" form:"category"`
+	Account     uint      `json:"account" form:"account"`
+	Payee       string    `json:"payee" form:"payee"`
+	Description string    `json:"description" form:"description"`
+	Notes       string    `json:"notes" form:"notes"`
+	Tags        []string  `json:"tags" form:"tags"`
+	// Splits divide the amount over several categories.
+	Splits []*splitParams `json:"splits" form:"-"`
+	// Duplicates decides what happens when the expenditure likely already exists.
+	Duplicates DuplicatePolicy `json:"duplicates" form:"duplicates"`
+	// Reference is only set by the importers.
+	Reference string `json:"-" form:"-"`
+	// RecurringID is only set when a recurring template is materialized.
+	RecurringID uint `json:"-" form:"-"`
+}
+
+// errAccountNotFound is returned when an expenditure refers to an unknown account.
+var errAccountNotFound = errors.New("account not found")
+
+// createExpenditure stores a new expenditure. It is used by the expenditure
+// endpoints as well as the importers so both behave the same.
+// Without a category, the category rules decide the category.
+// When the expenditure was merged into an existing one, that one is returned with merged set.
+func createExpenditure(direction models.Direction, params *expenditureParams) (expenditure *models.Expenditure, merged bool, err error) {
 	var category *models.Category
 
 	params.Category = strings.TrimSpace(params.Category)
 	if len(params.Category) != 0 {
 		category = &models.Category{Name: params.Category}
 		if q := db.DB.FirstOrCreate(category, "name = ?", category.Name); q.Error != nil {
-			log.Errorf("ExpenditureController::Create FirstOrCreate failed: '%v'.", q.Error)
-			return ctx.NoContent(http.StatusInternalServerError)
+			return nil, false, fmt.Errorf("FirstOrCreate failed: %v", q.Error)
 		}
 	}
 
-	expenditure.Amount = params.Amount
-	expenditure.Date = params.Date
-	expenditure.Category = category
+	account, err := findAccount(params.Account)
+	if err != nil {
+		return nil, false, errAccountNotFound
+	}
+
+	tags, err := findOrCreateTags(params.Tags)
+	if err != nil {
+		return nil, false, fmt.Errorf("Could not find tags: %v", err)
+	}
+
+	splits, err := buildSplits(params.Splits, params.Amount)
+	if err == errInvalidSplits {
+		return nil, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("Could not create splits: %v", err)
+	}
+
+	expenditure = &models.Expenditure{
+		Amount:      params.Amount,
+		Date:        params.Date,
+		Direction:   direction,
+		Payee:       strings.TrimSpace(params.Payee),
+		Description: strings.TrimSpace(params.Description),
+		Notes:       strings.TrimSpace(params.Notes),
+		Reference:   params.Reference,
+		RecurringID: params.RecurringID,
+		Category:    category,
+		Account:     account,
+		AccountID:   account.ID,
+		Tags:        tags,
+		Splits:      splits,
+	}
+
+	// Split expenditures are categorized by their splits.
+	if len(splits) == 0 {
+		if _, err := categorize(expenditure); err != nil {
+			return nil, false, fmt.Errorf("Could not apply category rules: %v", err)
+		}
+	}
+
+	matches, err := findDuplicates(expenditure)
+	if err != nil {
+		return nil, false, fmt.Errorf("Could not look for duplicates: %v", err)
+	}
+
+	if len(matches) > 0 {
+		switch params.Duplicates {
+		case DuplicatesReject:
+			duplicates := []*models.Expenditure{}
+			for _, match := range matches {
+				duplicates = append(duplicates, match.Expenditure)
+			}
+			return nil, false, &duplicateError{Duplicates: duplicates}
+		case DuplicatesMerge:
+			existing := matches[0].Expenditure
+			if err := mergeExpenditure(existing, expenditure); err != nil {
+				return nil, false, fmt.Errorf("Merge failed: %v", err)
+			}
+			return existing, true, nil
+		}
+	}
+
+	// The categories of the splits are created together with the expenditure
+	// so rejected expenditures leave nothing behind.
+	tx := db.DB.Begin()
+	if err := resolveSplitCategories(tx, expenditure.Splits); err != nil {
+		tx.Rollback()
+		return nil, false, fmt.Errorf("Could not create split categories: %v", err)
+	}
+	if q := tx.Create(expenditure); q.Error != nil {
+		tx.Rollback()
+		return nil, false, fmt.Errorf("Create failed: %v", q.Error)
+	}
+	if q := tx.Commit(); q.Error != nil {
+		return nil, false, fmt.Errorf("Create failed: %v", q.Error)
+	}
 
-	if q := db.DB.Create(expenditure); q.Error != nil {
-		log.Errorf("ExpenditureController::Create Create failed: '%v'.", q.Error)
+	for _, match := range matches {
+		if _, err := flagDuplicate(match.Expenditure, expenditure, match.Score, match.Reason); err != nil {
+			return expenditure, false, fmt.Errorf("Could not flag duplicate: %v", err)
+		}
+	}
+
+	return expenditure, false, nil
+}
+
+func (c *expenditureController) Create(ctx echo.Context) error {
+	params := &expenditureParams{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("ExpenditureController::Create Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if !params.Duplicates.Valid() {
+		log.Infof("ExpenditureController::Create Unknown duplicates policy `%s`.", params.Duplicates)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	expenditure, merged, err := createExpenditure(c.direction, params)
+	if err == errAccountNotFound {
+		log.Infof("ExpenditureController::Create Could not find account '%d'.", params.Account)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+	if err == errInvalidSplits {
+		log.Infof("ExpenditureController::Create Invalid splits: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+	if dupErr, ok := err.(*duplicateError); ok {
+		log.Infof("ExpenditureController::Create Rejected: %v.", dupErr)
+		return ctx.JSON(http.StatusConflict, echo.Map{
+			"duplicates": TransformExpenditure(dupErr.Duplicates...),
+		})
+	}
+	if err != nil {
+		log.Errorf("ExpenditureController::Create %v", err)
 		return ctx.NoContent(http.StatusInternalServerError)
 	}
 
+	if merged {
+		log.Infof("ExpenditureController::Create Merged into existing expenditure: %+v.", expenditure)
+		return ctx.JSON(http.StatusOK, TransformExpenditure(expenditure)[0])
+	}
+
 	log.Infof("ExpenditureController::Create Expenditure created: %+v.", expenditure)
 	return ctx.JSON(http.StatusCreated, TransformExpenditure(expenditure)[0])
 }
@@ -202,7 +456,7 @@ func (c *expenditureController) Update(ctx echo.Context) error {
 	}
 
 	expenditure := &models.Expenditure{}
-	if q := db.DB.Preload("Category").First(expenditure, "id = ?", id); q.Error != nil {
+	if q := preloadExpenditures(db.DB).First(expenditure, "id = ? AND direction = ?", id, c.direction); q.Error != nil {
 		if q.RecordNotFound() {
 			log.Infof("ExpenditureController::Update Expenditure '%d' not found.", id)
 			return ctx.NoContent(http.StatusNotFound)
@@ -213,9 +467,16 @@ func (c *expenditureController) Update(ctx echo.Context) error {
 	}
 
 	params := &struct {
-		Date     time.Time `json:"date" form:"date"`
-		Amount   *float64  `json:"amount" form:"amount"`
-		Category *string   `json:"category" form:"category"`
+		Date        time.Time `json:"date" form:"date"`
+		Amount      *float64  `json:"amount" form:"amount"`
+		Category    *string   `json:"category" form:"category"`
+		Account     *uint     `json:"account" form:"account"`
+		Payee       *string   `json:"payee" form:"payee"`
+		Description *string   `json:"description" form:"description"`
+		Notes       *string   `json:"notes" form:"notes"`
+		Tags        *[]string `json:"tags" form:"tags"`
+		// Splits replace the current splits, an empty list removes them.
+		Splits *[]*splitParams `json:"splits" form:"-"`
 	}{}
 
 	if err := ctx.Bind(params); err != nil {
@@ -249,9 +510,51 @@ func (c *expenditureController) Update(ctx echo.Context) error {
 	if !params.Date.IsZero() {
 		expenditure.Date = params.Date
 	}
+	if params.Payee != nil {
+		expenditure.Payee = strings.TrimSpace(*params.Payee)
+	}
+	if params.Description != nil {
+		expenditure.Description = strings.TrimSpace(*params.Description)
+	}
+	if params.Notes != nil {
+		expenditure.Notes = strings.TrimSpace(*params.Notes)
+	}
 
 	expenditure.Category = category
 
+	var splits []*models.ExpenditureSplit
+	if params.Splits != nil {
+		if splits, err = buildSplits(*params.Splits, expenditure.Amount); err != nil {
+			if err == errInvalidSplits {
+				log.Infof("ExpenditureController::Update Invalid splits: '%v'.", err)
+				return ctx.NoContent(http.StatusBadRequest)
+			}
+
+			log.Errorf("ExpenditureController::Update Could not build splits: '%v'.", err)
+			return ctx.NoContent(http.StatusInternalServerError)
+		}
+		expenditure.Splits = nil
+	} else if len(expenditure.Splits) > 0 {
+		sum := 0.0
+		for _, split := range expenditure.Splits {
+			sum += split.Amount
+		}
+		if !splitsMatch(sum, expenditure.Amount) {
+			log.Infof("ExpenditureController::Update Amount no longer matches the splits.")
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+	}
+
+	if params.Account != nil {
+		account, err := findAccount(*params.Account)
+		if err != nil {
+			log.Infof("ExpenditureController::Update Could not find account '%d': '%v'.", *params.Account, err)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		expenditure.Account = account
+		expenditure.AccountID = account.ID
+	}
+
 	q := db.DB.Save(expenditure)
 	if q.Error != nil {
 		log.Errorf("ExpenditureController::Update Update failed: '%v'.", q.Error)
@@ -263,6 +566,26 @@ func (c *expenditureController) Update(ctx echo.Context) error {
 		return ctx.NoContent(http.StatusNotFound)
 	}
 
+	if params.Splits != nil {
+		if err := replaceSplits(expenditure, splits); err != nil {
+			log.Errorf("ExpenditureController::Update Could not replace splits: '%v'.", err)
+			return ctx.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	if params.Tags != nil {
+		tags, err := findOrCreateTags(*params.Tags)
+		if err != nil {
+			log.Errorf("ExpenditureController::Update Could not find tags: '%v'.", err)
+			return ctx.NoContent(http.StatusInternalServerError)
+		}
+
+		if err := db.DB.Model(expenditure).Association("Tags").Replace(tags).Error; err != nil {
+			log.Errorf("ExpenditureController::Update Could not replace tags: '%v'.", err)
+			return ctx.NoContent(http.StatusInternalServerError)
+		}
+	}
+
 	log.Infof("ExpenditureController::Update Updated: %+v.", expenditure)
 	return ctx.JSON(http.StatusOK, TransformExpenditure(expenditure)[0])
 }
@@ -274,7 +597,7 @@ func (c *expenditureController) Delete(ctx echo.Context) error {
 		return ctx.NoContent(http.StatusBadRequest)
 	}
 
-	q := db.DB.Where("id = ?", id).Delete(&models.Expenditure{})
+	q := db.DB.Where("id = ? AND direction = ?", id, c.direction).Delete(&models.Expenditure{})
 	if q.Error != nil {
 		log.Errorf("ExpenditureController::Delete Delete failed: '%v'.", q.Error)
 		return ctx.NoContent(http.StatusInternalServerError)
@@ -290,4 +613,7 @@ func (c *expenditureController) Delete(ctx echo.Context) error {
 }
 
 // ExpenditureController Contains the actions for the 'expenditures' endpoint.
-var ExpenditureController expenditureController
+var ExpenditureController = expenditureController{direction: models.DirectionExpense}
+
+// IncomeController Contains the actions for the 'incomes' endpoint.
+var IncomeController = expenditureController{direction: models.DirectionIncome}
@@ -107,6 +107,9 @@ func IsExpectedExpenditureResponse(actual *ExpenditureResponse, expected *Expend
 	So(actual.ID, ShouldEqual, expected.ID)
 	So(actual.Amount, ShouldEqual, expected.Amount)
 	So(actual.Date.Format(time.RFC3339), ShouldEqual, expected.Date.Format(time.RFC3339))
+	So(actual.Payee, ShouldEqual, expected.Payee)
+	So(actual.Description, ShouldEqual, expected.Description)
+	So(actual.Notes, ShouldEqual, expected.Notes)
 
 	So(actual.Category != nil && expected.Category != nil || actual.Category == nil && expected.Category == nil, ShouldBeTrue)
 
@@ -742,6 +745,22 @@ func TestExpenditureControllerCreate(t *testing.T) {
 			},
 		})
 
+		tests = append(tests, test{
+			URL:                "/api/expenditures",
+			Method:             "post",
+			Endpoint:           ExpenditureController.Create,
+			ContentType:        "application/json",
+			PostData:           `{"amount": 43.2, "payee": " Colruyt ", "description": "groceries", "notes": "birthday party"}`,
+			ExpectedStatusCode: http.StatusCreated,
+			ExpectedExpenditureResponse: &ExpenditureResponse{
+				ID:          9,
+				Amount:      43.2,
+				Payee:       "Colruyt",
+				Description: "groceries",
+				Notes:       "birthday party",
+			},
+		})
+
 		tests = append(tests, test{
 			URL:                "/api/expenditures",
 			Method:             "post",
@@ -1138,3 +1157,152 @@ func TestExpenditureControllerDelete(t *testing.T) {
 		})
 	})
 }
+
+func TestExpenditureControllerSuggestCategory(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		groceries := &models.Category{Name: "groceries"}
+		car := &models.Category{Name: "car"}
+		db.DB.Create(groceries)
+		db.DB.Create(car)
+
+		date := time.Date(2017, 3, 4, 12, 0, 0, 0, time.Local)
+		for i, description := range []string{"COLRUYT GENT", "Colruyt Brugge", "Delhaize Gent", "ALDI 1234"} {
+			db.DB.Create(&models.Expenditure{Amount: 40 + float64(i), Date: date, Description: description, Direction: models.DirectionExpense, Category: groceries})
+		}
+		db.DB.Create(&models.Expenditure{Amount: 70, Date: date, Description: "SHELL GENT", Direction: models.DirectionExpense, Category: car})
+		db.DB.Create(&models.Expenditure{Amount: 65, Date: date, Description: "ESSO 4411", Direction: models.DirectionExpense, Category: car})
+
+		suggest := func(params url.Values) []*CategorySuggestionResponse {
+			r := httptest.NewRequest("GET", "/api/expenditures/suggest-category?"+params.Encode(), nil)
+			w := httptest.NewRecorder()
+			So(ExpenditureController.SuggestCategory(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			answer := &struct {
+				Data []*CategorySuggestionResponse `json:"data"`
+			}{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			return answer.Data
+		}
+
+		Convey("Suggesting a category from the description.", t, func() {
+			data := suggest(url.Values{"description": {"Colruyt Oostende"}, "amount": {"35.10"}})
+			So(len(data), ShouldEqual, 2)
+			So(data[0].Category.Name, ShouldEqual, "groceries")
+			So(data[0].Probability, ShouldBeGreaterThan, data[1].Probability)
+			So(data[0].Probability+data[1].Probability, ShouldAlmostEqual, 1)
+
+			data = suggest(url.Values{"description": {"Shell Oostende"}, "limit": {"1"}})
+			So(len(data), ShouldEqual, 1)
+			So(data[0].Category.Name, ShouldEqual, "car")
+		})
+
+		Convey("Archived categories are not suggested.", t, func() {
+			car.Archived = true
+			db.DB.Save(car)
+
+			data := suggest(url.Values{"description": {"Shell Oostende"}})
+			So(len(data), ShouldEqual, 1)
+			So(data[0].Category.Name, ShouldEqual, "groceries")
+		})
+
+		Convey("Checking invalid amounts.", t, func() {
+			r := httptest.NewRequest("GET", "/api/expenditures/suggest-category?amount=abc", nil)
+			w := httptest.NewRecorder()
+			So(ExpenditureController.SuggestCategory(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
+
+func TestExpenditureControllerIndexWildcards(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		now := time.Now()
+		db.DB.Create(&models.Expenditure{Amount: 10, Date: now, Payee: "100% Bio"})
+		db.DB.Create(&models.Expenditure{Amount: 20, Date: now, Payee: "1000 Bio"})
+		db.DB.Create(&models.Expenditure{Amount: 30, Date: now, Payee: "a_b"})
+		db.DB.Create(&models.Expenditure{Amount: 40, Date: now, Payee: "axb"})
+
+		index := func(query string) []*ExpenditureResponse {
+			r := httptest.NewRequest("GET", "/api/expenditures?"+query, nil)
+			w := httptest.NewRecorder()
+			So(ExpenditureController.Index(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			answer := &expenditureListResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			return answer.Data
+		}
+
+		Convey("Wildcards in filters match literally.", t, func() {
+			data := index(url.Values{"payee": {"100%"}}.Encode())
+			So(len(data), ShouldEqual, 1)
+			So(data[0].Payee, ShouldEqual, "100% Bio")
+
+			data = index(url.Values{"search": {"a_b"}}.Encode())
+			So(len(data), ShouldEqual, 1)
+			So(data[0].Payee, ShouldEqual, "a_b")
+		})
+	})
+}
+
+func TestExpenditureControllerIndexFilters(t *testing.T) {
+	withDb(func() {
+		now := time.Now()
+
+		db.DB.Create(&models.Expenditure{Amount: 10, Date: now, Payee: "Colruyt", Description: "groceries"})
+		db.DB.Create(&models.Expenditure{Amount: 20, Date: now, Payee: "Shell", Notes: "trip to the coast"})
+		db.DB.Create(&models.Expenditure{Amount: 30, Date: now, Payee: "Colruyt", Notes: "party"})
+
+		tests := []test{}
+		tests = append(tests, test{
+			URL:                "/api/expenditures?payee=colruyt&sort=id",
+			Method:             "get",
+			Endpoint:           ExpenditureController.Index,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedExpenditureListResponse: &expenditureListResponse{
+				Limit: 100,
+				Data: []*ExpenditureResponse{
+					{ID: 1, Amount: 10, Date: now, Payee: "Colruyt", Description: "groceries"},
+					{ID: 3, Amount: 30, Date: now, Payee: "Colruyt", Notes: "party"},
+				},
+			},
+		})
+
+		tests = append(tests, test{
+			URL:                "/api/expenditures?payee=colruyt&notes=party",
+			Method:             "get",
+			Endpoint:           ExpenditureController.Index,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedExpenditureListResponse: &expenditureListResponse{
+				Limit: 100,
+				Data: []*ExpenditureResponse{
+					{ID: 3, Amount: 30, Date: now, Payee: "Colruyt", Notes: "party"},
+				},
+			},
+		})
+
+		tests = append(tests, test{
+			URL:                "/api/expenditures?search=coast",
+			Method:             "get",
+			Endpoint:           ExpenditureController.Index,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedExpenditureListResponse: &expenditureListResponse{
+				Limit: 100,
+				Data: []*ExpenditureResponse{
+					{ID: 2, Amount: 20, Date: now, Payee: "Shell", Notes: "trip to the coast"},
+				},
+			},
+		})
+
+		Convey("Filtering expenditures.", t, func() {
+			for _, test := range tests {
+				doTest(&test)
+			}
+		})
+	})
+}
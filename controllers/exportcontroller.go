@@ -1,113 +1,163 @@
 package controllers
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"time"
 
 	"github.com/labstack/echo"
-	"github.com/tealeg/xlsx"
-	"github.com/trtstm/budgetr/db"
 	"github.com/trtstm/budgetr/log"
-	"github.com/trtstm/budgetr/models"
 )
 
-type exportController struct {
-}
+// excelMIME is the content type of an xlsx workbook.
+const excelMIME = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 
-func (c *exportController) ExportExcel(ctx echo.Context) error {
-	timeStart := time.Now()
-
-	params := []struct {
-		Start time.Time `json:"start" form:"start"`
-		End   time.Time `json:"end" form:"end"`
-		Title string    `json:"title" form:"title"`
-	}{}
+// bindExportRanges reads the ranges to export from the ranges form value or the request body.
+func bindExportRanges(ctx echo.Context) ([]exportRange, error) {
+	ranges := []exportRange{}
 
 	var err error
 	if len(ctx.FormValue("ranges")) != 0 {
-		err = json.Unmarshal([]byte(ctx.FormValue("ranges")), &params)
+		err = json.Unmarshal([]byte(ctx.FormValue("ranges")), &ranges)
 	} else {
-		err = ctx.Bind(&params)
+		err = ctx.Bind(&ranges)
 	}
 
+	return ranges, err
+}
+
+// transactionsLayout returns whether the flat list of transactions is requested instead of the summary.
+func transactionsLayout(ctx echo.Context) bool {
+	return ctx.QueryParam("layout") == "transactions"
+}
+
+type exportController struct {
+}
+
+// export binds the ranges and the locale, lets write generate the file in memory
+// and sends it as filename. Nothing is written to disk, so concurrent exports never mix.
+func (c *exportController) export(ctx echo.Context, action string, filename string, contentType string, write func(buf *bytes.Buffer, ranges []exportRange, locale *exportLocale) error) error {
+	timeStart := time.Now()
+
+	ranges, err := bindExportRanges(ctx)
 	if err != nil {
-		log.Info("ExportController::ExportExcel Failed to bind params: %v", err)
+		log.Infof("ExportController::%s Failed to bind params: %v", action, err)
 		return ctx.NoContent(http.StatusBadRequest)
 	}
 
-	results := map[string][]float64{}
+	locale, err := findExportLocale(ctx.FormValue("locale"))
+	if err != nil {
+		log.Infof("ExportController::%s Unknown locale `%s`.", action, ctx.FormValue("locale"))
+		return ctx.NoContent(http.StatusBadRequest)
+	}
 
-	categories := []models.Category{}
-	if q := db.DB.Find(&categories); q.Error != nil {
-		log.Info("ExportController::ExportExcel Failed to retrieve categories: %v", q.Error)
+	buf := &bytes.Buffer{}
+	if err := write(buf, ranges, locale); err != nil {
+		log.Errorf("ExportController::%s Could not create export: %v", action, err)
 		return ctx.NoContent(http.StatusInternalServerError)
 	}
-	categories = append(categories, models.Category{
-		Name: "",
+
+	elapsed := time.Since(timeStart)
+
+	log.Infof("ExportController::%s Generated %s (%s) in %s.", action, filename, locale.Name, elapsed)
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return ctx.Blob(http.StatusOK, contentType, buf.Bytes())
+}
+
+func (c *exportController) ExportExcel(ctx echo.Context) error {
+	return c.export(ctx, "ExportExcel", "export.xlsx", excelMIME, func(buf *bytes.Buffer, ranges []exportRange, locale *exportLocale) error {
+		file, err := buildExcelExport(ranges, locale)
+		if err != nil {
+			return err
+		}
+
+		return file.Write(buf)
+	})
+}
+
+func (c *exportController) ExportODS(ctx echo.Context) error {
+	return c.export(ctx, "ExportODS", "export.ods", odsMIME, func(buf *bytes.Buffer, ranges []exportRange, locale *exportLocale) error {
+		tables, err := exportTables(ranges, locale)
+		if err != nil {
+			return err
+		}
+
+		return writeODS(buf, tables, locale)
 	})
+}
+
+// ExportCSV exports the summary, or the transactions with layout=transactions.
+func (c *exportController) ExportCSV(ctx echo.Context) error {
+	return c.export(ctx, "ExportCSV", "export.csv", "text/csv; charset=utf-8", func(buf *bytes.Buffer, ranges []exportRange, locale *exportLocale) error {
+		tables, err := exportTables(ranges, locale)
+		if err != nil {
+			return err
+		}
 
-	for _, category := range categories {
-		results[category.Name] = make([]float64, len(params))
+		if transactionsLayout(ctx) {
+			return writeCSV(buf, tables[1], locale)
+		}
+		return writeCSV(buf, tables[0], locale)
+	})
+}
+
+// ExportJSON returns the summary, or the transactions with layout=transactions.
+func (c *exportController) ExportJSON(ctx echo.Context) error {
+	ranges, err := bindExportRanges(ctx)
+	if err != nil {
+		log.Infof("ExportController::ExportJSON Failed to bind params: %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
 	}
 
-	for i, r := range params {
-		q := categoryStatsQuery()
-		q = dateRangeQuery(r.Start, r.End, q)
-		stats := []*CategoryStatsResponse{}
-		q.Scan(&stats)
-		if q.Error != nil {
-			log.Errorf("ExportController::ExportExcel Could not execute query: %v", q.Error)
+	if transactionsLayout(ctx) {
+		transactions, err := exportTransactions(ranges)
+		if err != nil {
+			log.Errorf("ExportController::ExportJSON Could not retrieve transactions: %v", err)
 			return ctx.NoContent(http.StatusInternalServerError)
 		}
 
-		for _, stat := range stats {
-			results[stat.Name.String][i] += stat.Total
-		}
+		log.Infof("ExportController::ExportJSON Returning %d transactions.", len(transactions))
+		return ctx.JSON(http.StatusOK, echo.Map{
+			"data": transactions,
+		})
 	}
 
-	var file *xlsx.File
-	var sheet *xlsx.Sheet
-	var row *xlsx.Row
-	var cell *xlsx.Cell
-
-	file = xlsx.NewFile()
-	sheet, err = file.AddSheet("Uitgaves")
+	summary, err := buildExportSummary(ranges)
 	if err != nil {
-		log.Errorf("ExportController::ExportExcel Could not create excel file: %v", err)
+		log.Errorf("ExportController::ExportJSON Could not create summary: %v", err)
 		return ctx.NoContent(http.StatusInternalServerError)
 	}
 
-	headerRow := sheet.AddRow()
-	headerRow.AddCell().Value = "Categorie"
-	for _, param := range params {
-		headerRow.AddCell().Value = param.Title
-	}
-
-	for category, result := range results {
-		row = sheet.AddRow()
-		cell = row.AddCell()
-		if category == "" {
-			category = "geen"
-		}
-		cell.Value = category
+	log.Infof("ExportController::ExportJSON Returning summary of %d categories.", len(summary.Rows))
+	return ctx.JSON(http.StatusOK, summary)
+}
 
-		for _, total := range result {
-			cell := row.AddCell()
-			cell.SetFloat(total)
-		}
+// ExportJournal exports the expenditures, income and transfers between the
+// optional start and end as a ledger, hledger or beancount file.
+func (c *exportController) ExportJournal(ctx echo.Context) error {
+	format := ctx.Param("format")
+	layout, ok := journalFormats[format]
+	if !ok {
+		log.Infof("ExportController::ExportJournal Unknown format `%s`.", format)
+		return ctx.NoContent(http.StatusBadRequest)
 	}
 
-	err = file.Save("export.xlsx")
+	start, end, err := parseDateRange(ctx)
 	if err != nil {
-		log.Errorf("ExportController::ExportExcel Could not save excel file: %v", err)
-		return ctx.NoContent(http.StatusInternalServerError)
+		log.Infof("ExportController::ExportJournal %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
 	}
 
-	elapsed := time.Since(timeStart)
+	buf := &bytes.Buffer{}
+	if err := writeJournal(buf, format, start, end); err != nil {
+		log.Errorf("ExportController::ExportJournal Could not create journal: %v", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
 
-	log.Infof("ExportController::ExportExcel Generated excel in %s.", elapsed)
-	return ctx.Attachment("export.xlsx", "export.xlsx")
+	log.Infof("ExportController::ExportJournal Generated %s.", layout.Filename)
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+layout.Filename+`"`)
+	return ctx.Blob(http.StatusOK, "text/plain; charset=utf-8", buf.Bytes())
 }
 
 // ExportController for /exports endpoint.
@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/tealeg/xlsx"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// exportTotal returns the total of category in the first range of an exported workbook.
+func exportTotal(data []byte, category string) (float64, error) {
+	file, err := xlsx.OpenBinary(data)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, row := range file.Sheets[0].Rows {
+		if len(row.Cells) > 1 && row.Cells[0].Value == category {
+			return row.Cells[1].Float()
+		}
+	}
+
+	return 0, fmt.Errorf("category `%s` not found", category)
+}
+
+func TestExportControllerParallel(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		const months = 8
+		for i := 0; i < months; i++ {
+			date := time.Date(2017, time.Month(i+1), 10, 12, 0, 0, 0, time.UTC)
+			if _, _, err := createExpenditure(models.DirectionExpense, &expenditureParams{Date: date, Amount: float64(10 * (i + 1)), Category: "food"}); err != nil {
+				panic(err)
+			}
+		}
+
+		Convey("Exporting concurrently gives every request its own workbook.", t, func() {
+			results := make([]*httptest.ResponseRecorder, months)
+
+			wg := sync.WaitGroup{}
+			for i := 0; i < months; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+
+					start := time.Date(2017, time.Month(i+1), 1, 0, 0, 0, 0, time.UTC)
+					body := fmt.Sprintf(`[{"start": "%s", "end": "%s", "title": "month %d"}]`,
+						start.Format(time.RFC3339), start.AddDate(0, 1, 0).Format(time.RFC3339), i+1)
+
+					r := httptest.NewRequest("POST", "/api/exports/excel", strings.NewReader(body))
+					r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+					results[i] = httptest.NewRecorder()
+					ExportController.ExportExcel(e.NewContext(r, results[i]))
+				}(i)
+			}
+			wg.Wait()
+
+			for i, w := range results {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get(echo.HeaderContentDisposition), ShouldContainSubstring, "export.xlsx")
+
+				total, err := exportTotal(w.Body.Bytes(), "food")
+				So(err, ShouldBeNil)
+				So(total, ShouldEqual, 10*(i+1))
+			}
+		})
+	})
+}
+
+func TestBuildExcelExport(t *testing.T) {
+	withDb(func() {
+		create := func(date time.Time, amount float64, category string, payee string) {
+			if _, _, err := createExpenditure(models.DirectionExpense, &expenditureParams{Date: date, Amount: amount, Category: category, Payee: payee}); err != nil {
+				panic(err)
+			}
+		}
+
+		create(time.Date(2017, 1, 10, 12, 0, 0, 0, time.UTC), 700, "rent", "Landlord")
+		create(time.Date(2017, 1, 12, 12, 0, 0, 0, time.UTC), 40.5, "food", "Colruyt")
+		create(time.Date(2017, 2, 3, 12, 0, 0, 0, time.UTC), 20, "food", "Delhaize")
+		create(time.Date(2017, 2, 4, 12, 0, 0, 0, time.UTC), 5, "", "")
+
+		ranges := []exportRange{
+			{Start: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC), Title: "januari"},
+			{Start: time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), Title: "februari"},
+		}
+
+		Convey("Building the workbook.", t, func() {
+			locale, err := findExportLocale("")
+			So(err, ShouldBeNil)
+
+			file, err := buildExcelExport(ranges, locale)
+			So(err, ShouldBeNil)
+			So(len(file.Sheets), ShouldEqual, 2)
+
+			summary := file.Sheets[0]
+			So(summary.Name, ShouldEqual, "Uitgaves")
+			So(len(summary.Rows), ShouldEqual, 5)
+			So(summary.Rows[0].Cells[3].Value, ShouldEqual, "Totaal")
+			So(summary.Rows[1].Cells[0].Value, ShouldEqual, "food")
+			So(summary.Rows[2].Cells[0].Value, ShouldEqual, "rent")
+			So(summary.Rows[3].Cells[0].Value, ShouldEqual, "geen")
+			So(summary.Rows[1].Cells[1].NumFmt, ShouldEqual, `"€" #,##0.00`)
+			So(summary.Rows[1].Cells[3].Formula(), ShouldEqual, "SUM(B2:C2)")
+			So(summary.Rows[4].Cells[1].Formula(), ShouldEqual, "SUM(B2:B4)")
+
+			total, err := summary.Rows[4].Cells[3].Float()
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 765.5)
+
+			transactions := file.Sheets[1]
+			So(transactions.Name, ShouldEqual, "Transacties")
+			So(len(transactions.Rows), ShouldEqual, 6)
+			So(transactions.Rows[1].Cells[0].Value, ShouldEqual, "januari")
+			So(transactions.Rows[1].Cells[3].Value, ShouldEqual, "Landlord")
+			So(transactions.Rows[4].Cells[2].Value, ShouldEqual, "geen")
+			So(transactions.Rows[5].Cells[6].Formula(), ShouldEqual, "SUM(G2:G5)")
+
+			date, err := transactions.Rows[2].Cells[1].GetTime(false)
+			So(err, ShouldBeNil)
+			So(date.Format("2006-01-02"), ShouldEqual, "2017-01-12")
+			So(transactions.Rows[2].Cells[1].NumFmt, ShouldEqual, "dd/mm/yyyy")
+		})
+
+		Convey("Building a French workbook.", t, func() {
+			locale, err := findExportLocale("fr")
+			So(err, ShouldBeNil)
+
+			file, err := buildExcelExport(ranges, locale)
+			So(err, ShouldBeNil)
+
+			summary := file.Sheets[0]
+			So(summary.Name, ShouldEqual, "Dépenses")
+			So(summary.Rows[0].Cells[0].Value, ShouldEqual, "Catégorie")
+			So(summary.Rows[3].Cells[0].Value, ShouldEqual, "aucune")
+			So(summary.Rows[1].Cells[1].NumFmt, ShouldEqual, `#,##0.00 "€"`)
+			So(file.Sheets[1].Name, ShouldEqual, "Transactions")
+		})
+	})
+}
+
+func TestExportFormats(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		create := func(date time.Time, amount float64, category string, payee string) {
+			if _, _, err := createExpenditure(models.DirectionExpense, &expenditureParams{Date: date, Amount: amount, Category: category, Payee: payee}); err != nil {
+				panic(err)
+			}
+		}
+
+		create(time.Date(2017, 1, 10, 12, 0, 0, 0, time.UTC), 700, "rent", "Landlord")
+		create(time.Date(2017, 1, 12, 12, 0, 0, 0, time.UTC), 40.5, "food", "Colruyt & Co")
+		create(time.Date(2017, 2, 3, 12, 0, 0, 0, time.UTC), 20, "food", "Delhaize")
+
+		ranges := `[{"start": "2017-01-01T00:00:00Z", "end": "2017-02-01T00:00:00Z", "title": "januari"}, {"start": "2017-02-01T00:00:00Z", "end": "2017-03-01T00:00:00Z", "title": "februari"}]`
+
+		export := func(url string, endpoint echo.HandlerFunc) *httptest.ResponseRecorder {
+			r := httptest.NewRequest("POST", url, strings.NewReader(ranges))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			So(endpoint(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+			return w
+		}
+
+		Convey("Exporting CSV.", t, func() {
+			w := export("/api/exports/csv", ExportController.ExportCSV)
+			So(w.Header().Get(echo.HeaderContentDisposition), ShouldContainSubstring, "export.csv")
+
+			reader := csv.NewReader(w.Body)
+			reader.Comma = ';'
+			records, err := reader.ReadAll()
+			So(err, ShouldBeNil)
+			So(records[0], ShouldResemble, []string{"Categorie", "januari", "februari", "Totaal"})
+			So(records[1], ShouldResemble, []string{"food", "40,50", "20,00", "60,50"})
+			So(records[4], ShouldResemble, []string{"Totaal", "740,50", "20,00", "760,50"})
+
+			w = export("/api/exports/csv?layout=transactions&locale=en", ExportController.ExportCSV)
+			records, err = csv.NewReader(w.Body).ReadAll()
+			So(err, ShouldBeNil)
+			So(len(records), ShouldEqual, 5)
+			So(records[0], ShouldResemble, []string{"Period", "Date", "Category", "Payee", "Description", "Account", "Amount"})
+			So(records[2], ShouldResemble, []string{"januari", "01/12/2017", "food", "Colruyt & Co", "", "Default", "40.50"})
+		})
+
+		Convey("Exporting with an unknown locale.", t, func() {
+			r := httptest.NewRequest("POST", "/api/exports/csv?locale=xx", strings.NewReader(ranges))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			So(ExportController.ExportCSV(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Exporting JSON.", t, func() {
+			w := export("/api/exports/json", ExportController.ExportJSON)
+			summary := &exportSummary{}
+			So(json.NewDecoder(w.Body).Decode(summary), ShouldBeNil)
+			So(len(summary.Rows), ShouldEqual, 3)
+			So(summary.Rows[1].Category.String, ShouldEqual, "rent")
+			So(summary.Rows[1].Totals, ShouldResemble, []float64{700, 0})
+			So(summary.Rows[2].Category.Valid, ShouldBeFalse)
+			So(summary.Total, ShouldEqual, 760.5)
+
+			w = export("/api/exports/json?layout=transactions", ExportController.ExportJSON)
+			answer := &struct {
+				Data []*exportTransaction `json:"data"`
+			}{}
+			So(json.NewDecoder(w.Body).Decode(answer), ShouldBeNil)
+			So(len(answer.Data), ShouldEqual, 3)
+			So(answer.Data[2].Period, ShouldEqual, "februari")
+			So(answer.Data[2].Payee, ShouldEqual, "Delhaize")
+		})
+
+		Convey("Exporting ODS.", t, func() {
+			w := export("/api/exports/ods", ExportController.ExportODS)
+			data := w.Body.Bytes()
+
+			archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			So(err, ShouldBeNil)
+			So(archive.File[0].Name, ShouldEqual, "mimetype")
+			So(archive.File[0].Method, ShouldEqual, zip.Store)
+
+			var content []byte
+			for _, f := range archive.File {
+				if f.Name == "content.xml" {
+					r, err := f.Open()
+					So(err, ShouldBeNil)
+					content, err = ioutil.ReadAll(r)
+					So(err, ShouldBeNil)
+				}
+			}
+
+			So(string(content), ShouldContainSubstring, `table:name="Uitgaves"`)
+			So(string(content), ShouldContainSubstring, `table:formula="of:=SUM([.B2:.C2])"`)
+			So(string(content), ShouldContainSubstring, "Colruyt &amp; Co")
+			So(string(content), ShouldContainSubstring, `office:date-value="2017-01-12"><text:p>12/01/2017</text:p>`)
+			So(string(content), ShouldContainSubstring, `office:value="700"><text:p>€ 700,00</text:p>`)
+			So(string(content), ShouldContainSubstring, `number:language="nl" number:country="BE"`)
+		})
+	})
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+)
+
+// cellName returns the A1 style name of a 0-based cell.
+func cellName(col int, row int) string {
+	return xlsx.GetCellIDStringFromCoords(col, row)
+}
+
+// excelFile converts tables to a workbook with the number formats of locale.
+// Charts are not included because the xlsx library can not write them.
+func excelFile(tables []*exportTable, locale *exportLocale) (*xlsx.File, error) {
+	file := xlsx.NewFile()
+	for _, table := range tables {
+		sheet, err := file.AddSheet(table.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		for col, width := range table.Widths {
+			sheet.SetColWidth(col, col, width)
+		}
+
+		for _, cells := range table.Rows {
+			row := sheet.AddRow()
+			for _, c := range cells {
+				cell := row.AddCell()
+				switch c.Kind {
+				case exportAmount:
+					// The result is stored as well so programs that do not
+					// calculate formulas still show it.
+					cell.SetFloatWithFormat(c.Amount, locale.excelCurrencyFormat())
+					if c.Sum != nil {
+						cell.SetFormula("SUM(" + cellName(c.Sum.FromCol, c.Sum.FromRow) + ":" + cellName(c.Sum.ToCol, c.Sum.ToRow) + ")")
+					}
+				case exportDate:
+					cell.SetDateWithOptions(c.Date, xlsx.DateTimeOptions{
+						Location:        c.Date.Location(),
+						ExcelTimeFormat: locale.excelDateFormat(),
+					})
+				default:
+					cell.Value = c.Text
+				}
+			}
+		}
+	}
+
+	return file, nil
+}
+
+// buildExcelExport creates a workbook with a summary of the spending per
+// category in every range and a sheet with the individual expenses.
+func buildExcelExport(ranges []exportRange, locale *exportLocale) (*xlsx.File, error) {
+	tables, err := exportTables(ranges, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	return excelFile(tables, locale)
+}
+
+// writeCSV writes table as CSV with the delimiter, dates and decimal
+// separator of locale. Sums are written as their result.
+func writeCSV(w io.Writer, table *exportTable, locale *exportLocale) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = locale.CSVDelimiter
+	for _, cells := range table.Rows {
+		record := []string{}
+		for _, c := range cells {
+			switch c.Kind {
+			case exportAmount:
+				record = append(record, locale.formatNumber(c.Amount, false))
+			case exportDate:
+				record = append(record, c.Date.Format(locale.dateLayout()))
+			default:
+				record = append(record, c.Text)
+			}
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+const odsMIME = "application/vnd.oasis.opendocument.spreadsheet"
+
+const odsManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">
+ <manifest:file-entry manifest:full-path="/" manifest:media-type="` + odsMIME + `"/>
+ <manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>
+</manifest:manifest>
+`
+
+const odsContentStart = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:number="urn:oasis:names:tc:opendocument:xmlns:datastyle:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" xmlns:of="urn:oasis:names:tc:opendocument:xmlns:of:1.2" office:version="1.2">
+<office:automatic-styles>
+`
+
+const odsContentStyles = `<style:style style:name="amount" style:family="table-cell" style:data-style-name="Ncurrency"/>
+<style:style style:name="date" style:family="table-cell" style:data-style-name="Ndate"/>
+</office:automatic-styles>
+<office:body><office:spreadsheet>
+`
+
+const odsContentEnd = `</office:spreadsheet></office:body></office:document-content>
+`
+
+// xmlEscape escapes s for use in XML text and attributes.
+func xmlEscape(s string) string {
+	buf := &bytes.Buffer{}
+	xml.EscapeText(buf, []byte(s))
+	return buf.String()
+}
+
+// odsNumberStyles returns the currency and date styles of locale. The
+// language and country let spreadsheet programs pick the separators.
+func odsNumberStyles(locale *exportLocale) string {
+	language := fmt.Sprintf(`number:language="%s" number:country="%s"`, locale.Language, locale.Country)
+
+	symbol := `<number:currency-symbol ` + language + `>€</number:currency-symbol>`
+	number := `<number:number number:decimal-places="2" number:min-decimal-places="2" number:min-integer-digits="1" number:grouping="true"/>`
+	currency := symbol + `<number:text> </number:text>` + number
+	if !locale.CurrencyFirst {
+		currency = number + `<number:text> </number:text>` + symbol
+	}
+
+	date := []string{}
+	for _, c := range locale.DateOrder {
+		switch c {
+		case 'd':
+			date = append(date, `<number:day number:style="long"/>`)
+		case 'm':
+			date = append(date, `<number:month number:style="long"/>`)
+		case 'y':
+			date = append(date, `<number:year number:style="long"/>`)
+		}
+	}
+	separator := `<number:text>` + xmlEscape(locale.DateSeparator) + `</number:text>`
+
+	return fmt.Sprintf("<number:currency-style style:name=\"Ncurrency\" %s>%s</number:currency-style>\n<number:date-style style:name=\"Ndate\" %s>%s</number:date-style>\n",
+		language, currency, language, strings.Join(date, separator))
+}
+
+// odsContent returns the content.xml of an OpenDocument spreadsheet with
+// tables. The shown values are formatted in locale.
+func odsContent(tables []*exportTable, locale *exportLocale) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(odsContentStart)
+	buf.WriteString(odsNumberStyles(locale))
+	buf.WriteString(odsContentStyles)
+
+	for _, table := range tables {
+		fmt.Fprintf(buf, "<table:table table:name=\"%s\">\n", xmlEscape(table.Name))
+		for _, cells := range table.Rows {
+			buf.WriteString("<table:table-row>")
+			for _, c := range cells {
+				switch c.Kind {
+				case exportAmount:
+					amount := strconv.FormatFloat(c.Amount, 'f', -1, 64)
+					formula := ""
+					if c.Sum != nil {
+						formula = fmt.Sprintf(` table:formula="of:=SUM([.%s:.%s])"`, cellName(c.Sum.FromCol, c.Sum.FromRow), cellName(c.Sum.ToCol, c.Sum.ToRow))
+					}
+					fmt.Fprintf(buf, `<table:table-cell table:style-name="amount"%s office:value-type="currency" office:currency="EUR" office:value="%s"><text:p>%s</text:p></table:table-cell>`,
+						formula, amount, xmlEscape(locale.formatCurrency(c.Amount)))
+				case exportDate:
+					fmt.Fprintf(buf, `<table:table-cell table:style-name="date" office:value-type="date" office:date-value="%s"><text:p>%s</text:p></table:table-cell>`,
+						c.Date.Format("2006-01-02"), c.Date.Format(locale.dateLayout()))
+				default:
+					fmt.Fprintf(buf, `<table:table-cell office:value-type="string"><text:p>%s</text:p></table:table-cell>`, xmlEscape(c.Text))
+				}
+			}
+			buf.WriteString("</table:table-row>\n")
+		}
+		buf.WriteString("</table:table>\n")
+	}
+
+	buf.WriteString(odsContentEnd)
+	return buf.Bytes()
+}
+
+// writeODS writes tables as an OpenDocument spreadsheet formatted in locale.
+func writeODS(w io.Writer, tables []*exportTable, locale *exportLocale) error {
+	archive := zip.NewWriter(w)
+
+	// The mimetype has to be the first file and can not be compressed.
+	mimetype, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, odsMIME); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"META-INF/manifest.xml", []byte(odsManifest)},
+		{"content.xml", odsContent(tables, locale)},
+	}
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(file.data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
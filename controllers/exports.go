@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+)
+
+// exportRange is a titled period [Start, End) that becomes a column of an export.
+type exportRange struct {
+	Start time.Time `json:"start" form:"start"`
+	End   time.Time `json:"end" form:"end"`
+	Title string    `json:"title" form:"title"`
+}
+
+// exportTransaction is one expense in an export, or one split of it.
+type exportTransaction struct {
+	Period      string            `json:"period"`
+	Date        time.Time         `json:"date"`
+	Category    models.NullString `json:"category"`
+	Account     models.NullString `json:"account"`
+	Payee       string            `json:"payee"`
+	Description string            `json:"description"`
+	Amount      float64           `json:"amount"`
+}
+
+// exportTransactions returns the expenses in the ranges, ordered by date within every range.
+func exportTransactions(ranges []exportRange) ([]*exportTransaction, error) {
+	result := []*exportTransaction{}
+
+	for _, r := range ranges {
+		transactions := []*exportTransaction{}
+
+		q := categoryAmountsQuery(models.DirectionExpense)
+		q = q.Joins("LEFT JOIN accounts ON accounts.id = expenditures.account_id")
+		q = q.Select("expenditures.date AS date, categories.name AS category, accounts.name AS account, expenditures.payee AS payee, expenditures.description AS description, COALESCE(expenditure_splits.amount, expenditures.amount) AS amount")
+		q = dateRangeQuery(r.Start, r.End, q).Order("expenditures.date asc, expenditures.id asc, expenditure_splits.id asc")
+		if q = q.Scan(&transactions); q.Error != nil {
+			return nil, q.Error
+		}
+
+		for _, transaction := range transactions {
+			transaction.Period = r.Title
+		}
+		result = append(result, transactions...)
+	}
+
+	return result, nil
+}
+
+// exportSummaryRow is the spending of a category in every range of an export.
+type exportSummaryRow struct {
+	ID       uint              `json:"id"`
+	Category models.NullString `json:"category"`
+	Totals   []float64         `json:"totals"`
+	Total    float64           `json:"total"`
+}
+
+// exportSummary is the spending per category in every range of an export.
+type exportSummary struct {
+	Ranges []exportRange       `json:"ranges"`
+	Rows   []*exportSummaryRow `json:"rows"`
+	Totals []float64           `json:"totals"`
+	Total  float64             `json:"total"`
+}
+
+// buildExportSummary sums the spending per category in every range. The
+// categories are in their sort order, followed by the uncategorized spending.
+// Archived categories are left out unless something was spent in them.
+func buildExportSummary(ranges []exportRange) (*exportSummary, error) {
+	categories := []*models.Category{}
+	if q := db.DB.Find(&categories); q.Error != nil {
+		return nil, q.Error
+	}
+	sortCategories(categories)
+
+	summary := &exportSummary{Ranges: ranges, Rows: []*exportSummaryRow{}, Totals: make([]float64, len(ranges))}
+	rows := map[uint]*exportSummaryRow{}
+	for _, category := range categories {
+		row := &exportSummaryRow{ID: category.ID, Totals: make([]float64, len(ranges))}
+		row.Category.Set(category.Name)
+		rows[category.ID] = row
+	}
+	rows[0] = &exportSummaryRow{Totals: make([]float64, len(ranges))}
+
+	for i, r := range ranges {
+		q := categoryStatsQuery(models.DirectionExpense)
+		q = dateRangeQuery(r.Start, r.End, q)
+		stats := []*CategoryStatsResponse{}
+		if q = q.Scan(&stats); q.Error != nil {
+			return nil, q.Error
+		}
+
+		for _, stat := range stats {
+			if row, ok := rows[stat.ID]; ok {
+				row.Totals[i] += stat.Total
+				row.Total += stat.Total
+				summary.Totals[i] += stat.Total
+				summary.Total += stat.Total
+			}
+		}
+	}
+
+	for _, category := range categories {
+		if row := rows[category.ID]; !category.Archived || row.Total != 0 {
+			summary.Rows = append(summary.Rows, row)
+		}
+	}
+	summary.Rows = append(summary.Rows, rows[0])
+
+	return summary, nil
+}
+
+// exportCellKind is the type of the value of an exportCell.
+type exportCellKind int
+
+const (
+	exportText exportCellKind = iota
+	exportAmount
+	exportDate
+)
+
+// exportSum is a block of cells [FromCol, ToCol] x [FromRow, ToRow], all 0-based, that are added up.
+type exportSum struct {
+	FromCol int
+	FromRow int
+	ToCol   int
+	ToRow   int
+}
+
+// exportCell is a cell of an exportTable. Formats that support formulas
+// write Sum as a formula, the others write Amount, which holds its result.
+type exportCell struct {
+	Kind   exportCellKind
+	Text   string
+	Amount float64
+	Date   time.Time
+	Sum    *exportSum
+}
+
+func textCell(text string) *exportCell {
+	return &exportCell{Kind: exportText, Text: text}
+}
+
+func amountCell(amount float64) *exportCell {
+	return &exportCell{Kind: exportAmount, Amount: amount}
+}
+
+func dateCell(date time.Time) *exportCell {
+	return &exportCell{Kind: exportDate, Date: date}
+}
+
+func sumCell(total float64, sum *exportSum) *exportCell {
+	return &exportCell{Kind: exportAmount, Amount: total, Sum: sum}
+}
+
+// exportTable is a sheet of an export, independent of the file format.
+type exportTable struct {
+	Name string
+	// Widths are the widths of the columns in characters.
+	Widths []float64
+	Rows   [][]*exportCell
+}
+
+// summaryTable lays out summary with the totals per category in the last
+// column and the totals per range in the last row.
+func summaryTable(summary *exportSummary, locale *exportLocale) *exportTable {
+	columns := len(summary.Ranges)
+	table := &exportTable{Name: locale.message("expenses"), Widths: []float64{25}}
+
+	header := []*exportCell{textCell(locale.message("category"))}
+	for _, r := range summary.Ranges {
+		header = append(header, textCell(r.Title))
+		table.Widths = append(table.Widths, 14)
+	}
+	if columns > 0 {
+		header = append(header, textCell(locale.message("total")))
+		table.Widths = append(table.Widths, 14)
+	}
+	table.Rows = append(table.Rows, header)
+
+	for _, row := range summary.Rows {
+		name := locale.message("uncategorized")
+		if row.Category.Valid {
+			name = row.Category.String
+		}
+
+		cells := []*exportCell{textCell(name)}
+		for _, total := range row.Totals {
+			cells = append(cells, amountCell(total))
+		}
+		if columns > 0 {
+			r := len(table.Rows)
+			cells = append(cells, sumCell(row.Total, &exportSum{1, r, columns, r}))
+		}
+		table.Rows = append(table.Rows, cells)
+	}
+
+	if columns > 0 {
+		last := len(table.Rows) - 1
+		cells := []*exportCell{textCell(locale.message("total"))}
+		for i, total := range append(summary.Totals, summary.Total) {
+			cells = append(cells, sumCell(total, &exportSum{i + 1, 1, i + 1, last}))
+		}
+		table.Rows = append(table.Rows, cells)
+	}
+
+	return table
+}
+
+// transactionsTable lists the transactions followed by their total.
+func transactionsTable(transactions []*exportTransaction, locale *exportLocale) *exportTable {
+	table := &exportTable{Name: locale.message("transactions"), Widths: []float64{20, 12, 25, 25, 25, 25, 14}}
+	header := []*exportCell{}
+	for _, key := range []string{"period", "date", "category", "payee", "description", "account", "amount"} {
+		header = append(header, textCell(locale.message(key)))
+	}
+	table.Rows = append(table.Rows, header)
+
+	total := 0.0
+	for _, transaction := range transactions {
+		category := locale.message("uncategorized")
+		if transaction.Category.Valid {
+			category = transaction.Category.String
+		}
+
+		table.Rows = append(table.Rows, []*exportCell{
+			textCell(transaction.Period),
+			dateCell(transaction.Date),
+			textCell(category),
+			textCell(transaction.Payee),
+			textCell(transaction.Description),
+			textCell(transaction.Account.String),
+			amountCell(transaction.Amount),
+		})
+		total += transaction.Amount
+	}
+
+	last := len(table.Rows) - 1
+	table.Rows = append(table.Rows, []*exportCell{
+		textCell(locale.message("total")), textCell(""), textCell(""), textCell(""), textCell(""), textCell(""),
+		sumCell(total, &exportSum{6, 1, 6, last}),
+	})
+
+	return table
+}
+
+// exportTables returns the summary and the transactions of the ranges as tables labeled in locale.
+func exportTables(ranges []exportRange, locale *exportLocale) ([]*exportTable, error) {
+	summary, err := buildExportSummary(ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := exportTransactions(ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*exportTable{summaryTable(summary, locale), transactionsTable(transactions, locale)}, nil
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+const (
+	// defaultForecastWeeks is the length of a forecast when no weeks are given.
+	defaultForecastWeeks = 4
+	// maxForecastWeeks limits the length of a forecast.
+	maxForecastWeeks = 52
+	// forecastHistoryDays is how far back the averages of the other expenditures are taken.
+	forecastHistoryDays = 90
+)
+
+// ForecastItemResponse is an expected occurrence of a recurring template.
+type ForecastItemResponse struct {
+	Recurring uint              `json:"recurring"`
+	Name      string            `json:"name"`
+	Amount    float64           `json:"amount"`
+	Direction models.Direction  `json:"direction"`
+	Category  *CategoryResponse `json:"category"`
+}
+
+// ForecastDayResponse holds what is expected to happen on a day and the balance at the end of it.
+type ForecastDayResponse struct {
+	Date     time.Time               `json:"date"`
+	Income   float64                 `json:"income"`
+	Spending float64                 `json:"spending"`
+	Balance  float64                 `json:"balance"`
+	Items    []*ForecastItemResponse `json:"items"`
+}
+
+// ForecastResponse is the projected balance for the coming days.
+type ForecastResponse struct {
+	// Balance is the balance at the end of today.
+	Balance float64 `json:"balance"`
+	// AverageIncome and AverageSpending are the daily averages of the
+	// expenditures that were not created from a recurring template.
+	AverageIncome   float64   `json:"average_income"`
+	AverageSpending float64   `json:"average_spending"`
+	LowestBalance   float64   `json:"lowest_balance"`
+	LowestDate      time.Time `json:"lowest_date"`
+	// BelowZero is the first day the balance is expected to be negative.
+	BelowZero *time.Time             `json:"below_zero"`
+	Days      []*ForecastDayResponse `json:"days"`
+}
+
+// buildForecast projects the balance of account, or of all accounts when
+// account is nil, for the given number of weeks after the day of now.
+// Recurring templates provide the known expenditures, the daily average of
+// the other expenditures over the last forecastHistoryDays covers the rest.
+func buildForecast(now time.Time, weeks int, account *models.Account) (*ForecastResponse, error) {
+	start := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	end := start.AddDate(0, 0, 7*weeks)
+
+	forecast := &ForecastResponse{Days: []*ForecastDayResponse{}}
+
+	balances, err := accountBalances(start)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []*models.Account{}
+	if account != nil {
+		accounts = append(accounts, account)
+	} else if q := db.DB.Find(&accounts); q.Error != nil {
+		return nil, q.Error
+	}
+	for _, a := range accounts {
+		forecast.Balance += a.OpeningBalance + balances[a.ID]
+	}
+
+	totals := []*struct {
+		Direction models.Direction
+		Total     float64
+	}{}
+	q := db.DB.Table("expenditures").Where("deleted_at IS NULL AND recurring_id = 0")
+	q = q.Group("direction").Select("direction, SUM(amount) AS total")
+	if account != nil {
+		q = q.Where("account_id = ?", account.ID)
+	}
+	if q = dateRangeQuery(start.AddDate(0, 0, -forecastHistoryDays), start, q).Scan(&totals); q.Error != nil {
+		return nil, q.Error
+	}
+	for _, total := range totals {
+		if total.Direction == models.DirectionIncome {
+			forecast.AverageIncome = total.Total / forecastHistoryDays
+		} else {
+			forecast.AverageSpending = total.Total / forecastHistoryDays
+		}
+	}
+
+	recurrings := []*models.Recurring{}
+	if q := db.DB.Preload("Category").Where("paused = ?", false).Find(&recurrings); q.Error != nil {
+		return nil, q.Error
+	}
+
+	defaultAccount, err := findAccount(0)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := forecast.Balance
+	forecast.LowestBalance = balance
+	forecast.LowestDate = start.AddDate(0, 0, -1)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		result := &ForecastDayResponse{
+			Date:     day,
+			Income:   forecast.AverageIncome,
+			Spending: forecast.AverageSpending,
+			Items:    []*ForecastItemResponse{},
+		}
+
+		for _, recurring := range recurrings {
+			accountID := recurring.AccountID
+			if accountID == 0 {
+				accountID = defaultAccount.ID
+			}
+			if account != nil && accountID != account.ID {
+				continue
+			}
+
+			// Occurrences that are still due before tomorrow are not created yet but will be soon.
+			for n := recurring.Count; ; n++ {
+				date := recurring.Occurrence(n)
+				if !date.Before(next) || recurring.Ended(date) {
+					break
+				}
+				if date.Before(day) && !day.Equal(start) {
+					continue
+				}
+
+				item := &ForecastItemResponse{
+					Recurring: recurring.ID,
+					Name:      recurring.Name,
+					Amount:    recurring.Amount,
+					Direction: recurring.Direction,
+				}
+				if recurring.Category != nil {
+					item.Category = TransformCategory(recurring.Category)[0]
+				}
+				result.Items = append(result.Items, item)
+
+				if recurring.Direction == models.DirectionIncome {
+					result.Income += recurring.Amount
+				} else {
+					result.Spending += recurring.Amount
+				}
+			}
+		}
+
+		balance += result.Income - result.Spending
+		result.Balance = balance
+		forecast.Days = append(forecast.Days, result)
+
+		if balance < forecast.LowestBalance {
+			forecast.LowestBalance = balance
+			forecast.LowestDate = day
+		}
+		if balance < 0 && forecast.BelowZero == nil {
+			belowZero := day
+			forecast.BelowZero = &belowZero
+		}
+	}
+
+	return forecast, nil
+}
+
+type forecastController struct {
+}
+
+func (c *forecastController) Index(ctx echo.Context) error {
+	weeks := defaultForecastWeeks
+	if weeksQ := ctx.QueryParam("weeks"); len(weeksQ) > 0 {
+		tmp, err := strconv.Atoi(weeksQ)
+		if err != nil || tmp < 1 || tmp > maxForecastWeeks {
+			log.Infof("ForecastController::Index Invalid weeks `%s`.", weeksQ)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		weeks = tmp
+	}
+
+	var account *models.Account
+	if accountQ := ctx.QueryParam("account"); len(accountQ) > 0 {
+		id, err := strconv.ParseUint(accountQ, 10, 64)
+		if err != nil || id == 0 {
+			log.Infof("ForecastController::Index Could not parse account `%s`.", accountQ)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+
+		if account, err = findAccount(uint(id)); err != nil {
+			log.Infof("ForecastController::Index Account '%d' not found.", id)
+			return ctx.NoContent(http.StatusNotFound)
+		}
+	}
+
+	forecast, err := buildForecast(time.Now(), weeks, account)
+	if err != nil {
+		log.Errorf("ForecastController::Index Could not build forecast: %v", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.WithFields(log.Fields{"weeks": weeks, "balance": forecast.Balance, "lowest": forecast.LowestBalance}).Infof("Returning forecast.")
+	return ctx.JSON(http.StatusOK, forecast)
+}
+
+// ForecastController for /forecast endpoint.
+var ForecastController forecastController
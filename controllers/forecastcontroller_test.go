@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestForecast(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		now := time.Date(2017, 6, 10, 15, 0, 0, 0, time.Local)
+		tomorrow := time.Date(2017, 6, 11, 0, 0, 0, 0, time.Local)
+
+		db.DB.Model(&models.Account{}).Where("name = ?", models.DefaultAccountName).UpdateColumn("opening_balance", 1000)
+		_, _, err := createExpenditure(models.DirectionExpense, &expenditureParams{Date: now.AddDate(0, 0, -10), Amount: 900, Category: "groceries"})
+		if err != nil {
+			panic(err)
+		}
+		db.DB.Create(&models.Recurring{Name: "Rent", Amount: 200, Direction: models.DirectionExpense, Unit: models.RecurrenceMonthly, Interval: 1,
+			StartDate: tomorrow.AddDate(0, 0, 2), NextDate: tomorrow.AddDate(0, 0, 2)})
+
+		Convey("Projecting the balance.", t, func() {
+			forecast, err := buildForecast(now, 1, nil)
+			So(err, ShouldBeNil)
+			So(forecast.Balance, ShouldAlmostEqual, 100)
+			So(forecast.AverageSpending, ShouldAlmostEqual, 10)
+			So(len(forecast.Days), ShouldEqual, 7)
+
+			So(forecast.Days[0].Date.Equal(tomorrow), ShouldBeTrue)
+			So(forecast.Days[0].Balance, ShouldAlmostEqual, 90)
+			So(forecast.Days[2].Spending, ShouldAlmostEqual, 210)
+			So(forecast.Days[2].Items[0].Name, ShouldEqual, "Rent")
+			So(forecast.Days[6].Balance, ShouldAlmostEqual, -170)
+
+			So(forecast.BelowZero.Equal(tomorrow.AddDate(0, 0, 2)), ShouldBeTrue)
+			So(forecast.LowestBalance, ShouldAlmostEqual, -170)
+		})
+
+		Convey("Invalid parameters are rejected.", t, func() {
+			for _, url := range []string{"/api/forecast?weeks=0", "/api/forecast?weeks=x", "/api/forecast?account=x"} {
+				r := httptest.NewRequest("GET", url, nil)
+				w := httptest.NewRecorder()
+				So(ForecastController.Index(e.NewContext(r, w)), ShouldBeNil)
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+			}
+
+			r := httptest.NewRequest("GET", "/api/forecast?account=1234", nil)
+			w := httptest.NewRecorder()
+			So(ForecastController.Index(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Getting the forecast.", t, func() {
+			r := httptest.NewRequest("GET", "/api/forecast?weeks=2", nil)
+			w := httptest.NewRecorder()
+			So(ForecastController.Index(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			forecast := &ForecastResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(forecast), ShouldBeNil)
+			So(len(forecast.Days), ShouldEqual, 14)
+		})
+	})
+}
@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/imports"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+// ErrImportProfileNotFound is returned when an import refers to an unknown profile.
+var ErrImportProfileNotFound = errors.New("import profile not found")
+
+// ImportOptions configures where and how records are imported.
+type ImportOptions struct {
+	// AccountID is the account to import into, 0 means the default account.
+	AccountID uint
+	// Duplicates decides what happens with records that likely already exist.
+	Duplicates DuplicatePolicy
+}
+
+// ImportResponse summarizes the result of an import. Remaining counts the
+// records that were not imported because storing one of them failed.
+type ImportResponse struct {
+	Created      int                    `json:"created"`
+	Merged       int                    `json:"merged"`
+	Skipped      int                    `json:"skipped"`
+	Remaining    int                    `json:"remaining"`
+	Expenditures []*ExpenditureResponse `json:"expenditures"`
+}
+
+// ImportRecords stores imported records in the given account through the same
+// path as the expenditure endpoints. Negative amounts become expenditures,
+// positive amounts become income. Records that name an existing account, like
+// those of a journal, go to that account instead. Empty amounts and records
+// whose bank reference was already imported in the account are skipped. Other
+// likely duplicates are handled according to options.Duplicates.
+//
+// Records are stored one at a time. When storing a record fails, the import
+// stops and the partial result is returned with the error: the records before
+// it stay imported and Remaining counts the failed record and those after it.
+// Importing the file again skips the records with a bank reference, the others
+// are handled as likely duplicates.
+func ImportRecords(records []*imports.Record, options ImportOptions) (*ImportResponse, error) {
+	if !options.Duplicates.Valid() {
+		return nil, errInvalidDuplicatePolicy
+	}
+
+	defaultAccount, err := findAccount(options.AccountID)
+	if err != nil {
+		return nil, errAccountNotFound
+	}
+
+	accounts := []*models.Account{}
+	if q := db.DB.Find(&accounts); q.Error != nil {
+		return nil, q.Error
+	}
+	accountNames := []string{}
+	for _, account := range accounts {
+		accountNames = append(accountNames, account.Name)
+	}
+
+	categoryNames := []string{}
+	if q := db.DB.Model(&models.Category{}).Pluck("name", &categoryNames); q.Error != nil {
+		return nil, q.Error
+	}
+
+	result := &ImportResponse{Expenditures: []*ExpenditureResponse{}}
+	for i, record := range records {
+		if record.Amount == 0 {
+			result.Skipped++
+			continue
+		}
+
+		account := defaultAccount
+		if record.Account != "" {
+			name := matchJournalName(record.Account, accountNames)
+			for _, a := range accounts {
+				if a.Name == name {
+					account = a
+				}
+			}
+		}
+
+		// Deleted expenditures are included so removed transactions do not come back.
+		if record.Reference != "" {
+			count := 0
+			q := db.DB.Unscoped().Model(&models.Expenditure{}).Where("account_id = ? AND reference = ?", account.ID, record.Reference).Count(&count)
+			if q.Error != nil {
+				result.Remaining = len(records) - i
+				return result, q.Error
+			}
+			if count > 0 {
+				result.Skipped++
+				continue
+			}
+		}
+
+		direction := models.DirectionIncome
+		if record.Amount < 0 {
+			direction = models.DirectionExpense
+		}
+
+		params := &expenditureParams{
+			Date:        record.Date,
+			Amount:      math.Abs(record.Amount),
+			Account:     account.ID,
+			Payee:       record.Payee,
+			Description: record.Description,
+			Tags:        record.Tags,
+			Duplicates:  options.Duplicates,
+			Reference:   record.Reference,
+		}
+		if record.Category != "" {
+			params.Category = matchJournalName(record.Category, categoryNames)
+		}
+		for _, split := range record.Splits {
+			category := split.Category
+			if category != "" {
+				category = matchJournalName(category, categoryNames)
+			}
+			params.Splits = append(params.Splits, &splitParams{Category: category, Amount: split.Amount})
+		}
+
+		expenditure, merged, err := createExpenditure(direction, params)
+		if _, ok := err.(*duplicateError); ok {
+			result.Skipped++
+			continue
+		}
+		if err != nil {
+			result.Remaining = len(records) - i
+			return result, err
+		}
+
+		if merged {
+			result.Merged++
+			continue
+		}
+
+		result.Created++
+		result.Expenditures = append(result.Expenditures, TransformExpenditure(expenditure)[0])
+	}
+
+	return result, nil
+}
+
+// ImportCSV parses a CSV bank statement with the named profile and imports its transactions.
+func ImportCSV(r io.Reader, profileName string, options ImportOptions) (*ImportResponse, error) {
+	profile, err := findImportProfile(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := imports.ParseCSV(r, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return ImportRecords(records, options)
+}
+
+// ImportStatement parses a structured bank statement (coda, camt053 or ofx) or
+// a journal (ledger, hledger or beancount) and imports its transactions.
+func ImportStatement(r io.Reader, format string, options ImportOptions) (*ImportResponse, error) {
+	records, err := imports.ParseStatement(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return ImportRecords(records, options)
+}
+
+// isImportInputError returns whether err was caused by the uploaded file or parameters.
+func isImportInputError(err error) bool {
+	if _, ok := err.(*imports.ParseError); ok {
+		return true
+	}
+
+	return err == ErrImportProfileNotFound || err == errAccountNotFound || err == errInvalidDuplicatePolicy || err == imports.ErrUnknownFormat
+}
+
+type importController struct {
+}
+
+// importUpload runs importer on the uploaded file and responds with the result.
+func (c *importController) importUpload(ctx echo.Context, action string, importer func(io.Reader, ImportOptions) (*ImportResponse, error)) error {
+	options := ImportOptions{Duplicates: DuplicatePolicy(ctx.FormValue("duplicates"))}
+	if accountQ := ctx.FormValue("account"); len(accountQ) > 0 {
+		tmp, err := strconv.ParseUint(accountQ, 10, 64)
+		if err != nil {
+			log.Infof("ImportController::%s Could not parse account `%s`: '%v'.", action, accountQ, err)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		options.AccountID = uint(tmp)
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		log.Infof("ImportController::%s No file given: '%v'.", action, err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		log.Errorf("ImportController::%s Could not open file: '%v'.", action, err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	defer src.Close()
+
+	result, err := importer(src, options)
+	if err != nil {
+		if isImportInputError(err) {
+			log.Infof("ImportController::%s Could not import '%s': '%v'.", action, file.Filename, err)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+
+		log.Errorf("ImportController::%s Could not import '%s': '%v'.", action, file.Filename, err)
+		// Tell what was imported before the failure so it can be checked before trying again.
+		if result != nil && result.Created+result.Merged > 0 {
+			return ctx.JSON(http.StatusInternalServerError, result)
+		}
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.WithFields(log.Fields{"file": file.Filename, "created": result.Created, "merged": result.Merged, "skipped": result.Skipped}).Infof("ImportController::%s Imported file.", action)
+	return ctx.JSON(http.StatusCreated, result)
+}
+
+func (c *importController) ImportCSV(ctx echo.Context) error {
+	profile := ctx.FormValue("profile")
+	return c.importUpload(ctx, "ImportCSV", func(r io.Reader, options ImportOptions) (*ImportResponse, error) {
+		return ImportCSV(r, profile, options)
+	})
+}
+
+func (c *importController) ImportStatement(ctx echo.Context) error {
+	format := ctx.Param("format")
+	return c.importUpload(ctx, "ImportStatement", func(r io.Reader, options ImportOptions) (*ImportResponse, error) {
+		return ImportStatement(r, format, options)
+	})
+}
+
+// ImportController for /imports endpoint.
+var ImportController importController
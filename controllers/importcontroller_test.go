@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/imports"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testOFX = `<OFX><BANKTRANLIST>
+<STMTTRN><DTPOSTED>20170305<TRNAMT>-43.20<FITID>A1<NAME>Colruyt</STMTTRN>
+<STMTTRN><DTPOSTED>20170306<TRNAMT>2500.00<FITID>A2<NAME>Employer</STMTTRN>
+<STMTTRN><DTPOSTED>20170307<TRNAMT>0.00<FITID>A3<NAME>Card check</STMTTRN>
+</BANKTRANLIST></OFX>`
+
+func TestImportStatement(t *testing.T) {
+	withDb(func() {
+		Convey("Importing a statement.", t, func() {
+			result, err := ImportStatement(strings.NewReader(testOFX), "ofx", ImportOptions{})
+			So(err, ShouldBeNil)
+			So(result.Created, ShouldEqual, 2)
+			So(result.Skipped, ShouldEqual, 1)
+
+			So(result.Expenditures[0].Direction, ShouldEqual, models.DirectionExpense)
+			So(result.Expenditures[0].Amount, ShouldAlmostEqual, 43.2)
+			So(result.Expenditures[0].Description, ShouldEqual, "Colruyt")
+			So(result.Expenditures[1].Direction, ShouldEqual, models.DirectionIncome)
+		})
+
+		Convey("Importing the same statement again.", t, func() {
+			result, err := ImportStatement(strings.NewReader(testOFX), "ofx", ImportOptions{})
+			So(err, ShouldBeNil)
+			So(result.Created, ShouldEqual, 0)
+			So(result.Skipped, ShouldEqual, 3)
+
+			count := 0
+			db.DB.Model(&models.Expenditure{}).Count(&count)
+			So(count, ShouldEqual, 2)
+		})
+
+		Convey("Importing into an unknown account.", t, func() {
+			_, err := ImportStatement(strings.NewReader(testOFX), "ofx", ImportOptions{AccountID: 1234})
+			So(err, ShouldEqual, errAccountNotFound)
+			So(isImportInputError(err), ShouldBeTrue)
+		})
+	})
+}
+
+func TestImportRecordsPartly(t *testing.T) {
+	withDb(func() {
+		date := time.Date(2017, 3, 5, 0, 0, 0, 0, time.Local)
+		records := []*imports.Record{
+			{Date: date, Amount: -10, Reference: "R1"},
+			{Date: date, Amount: -20, Reference: "R2"},
+			{Date: date, Amount: -30, Reference: "R3"},
+		}
+
+		db.DB.Exec("CREATE TRIGGER fail_import BEFORE INSERT ON expenditures WHEN NEW.amount = 20 BEGIN SELECT RAISE(ABORT, 'disk full'); END")
+
+		Convey("A failing record stops the import and returns what was imported.", t, func() {
+			result, err := ImportRecords(records, ImportOptions{})
+			So(err, ShouldNotBeNil)
+			So(result.Created, ShouldEqual, 1)
+			So(result.Remaining, ShouldEqual, 2)
+			So(result.Expenditures[0].Amount, ShouldEqual, 10)
+		})
+	})
+}
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/imports"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+type importProfileParams struct {
+	Name               string `json:"name" form:"name"`
+	Delimiter          string `json:"delimiter" form:"delimiter"`
+	SkipRows           int    `json:"skip_rows" form:"skip_rows"`
+	DateColumn         int    `json:"date_column" form:"date_column"`
+	DateFormat         string `json:"date_format" form:"date_format"`
+	AmountColumn       int    `json:"amount_column" form:"amount_column"`
+	DecimalSeparator   string `json:"decimal_separator" form:"decimal_separator"`
+	ThousandsSeparator string `json:"thousands_separator" form:"thousands_separator"`
+	DescriptionColumn  int    `json:"description_column" form:"description_column"`
+	PayeeColumn        int    `json:"payee_column" form:"payee_column"`
+}
+
+func (p *importProfileParams) apply(profile *models.ImportProfile) {
+	profile.Name = strings.TrimSpace(p.Name)
+	profile.Delimiter = p.Delimiter
+	profile.SkipRows = p.SkipRows
+	profile.DateColumn = p.DateColumn
+	profile.DateFormat = strings.TrimSpace(p.DateFormat)
+	profile.AmountColumn = p.AmountColumn
+	profile.DecimalSeparator = p.DecimalSeparator
+	profile.ThousandsSeparator = p.ThousandsSeparator
+	profile.DescriptionColumn = p.DescriptionColumn
+	profile.PayeeColumn = p.PayeeColumn
+
+	if profile.Delimiter == "" {
+		profile.Delimiter = ","
+	}
+	if profile.DecimalSeparator == "" {
+		profile.DecimalSeparator = "."
+	}
+}
+
+// findImportProfile looks up a profile by name, or by id when no profile has that name.
+func findImportProfile(nameOrID string) (*models.ImportProfile, error) {
+	profile := &models.ImportProfile{}
+
+	q := db.DB.Where("name = ?", nameOrID).First(profile)
+	if q.RecordNotFound() {
+		if id, err := strconv.ParseUint(nameOrID, 10, 64); err == nil {
+			q = db.DB.Where("id = ?", id).First(profile)
+		}
+	}
+
+	if q.RecordNotFound() {
+		return nil, ErrImportProfileNotFound
+	}
+	if q.Error != nil {
+		return nil, q.Error
+	}
+
+	return profile, nil
+}
+
+type importProfileController struct {
+}
+
+func (c *importProfileController) Index(ctx echo.Context) error {
+	profiles := []*models.ImportProfile{}
+
+	if q := db.DB.Order("name asc").Find(&profiles); q.Error != nil {
+		log.Errorf("ImportProfileController::Index Could not execute find query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("ImportProfileController::Index Returning %d profiles.", len(profiles))
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data": TransformImportProfile(profiles...),
+	})
+}
+
+func (c *importProfileController) Create(ctx echo.Context) error {
+	params := &importProfileParams{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("ImportProfileController::Create Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	profile := &models.ImportProfile{}
+	params.apply(profile)
+
+	if len(profile.Name) == 0 {
+		log.Infof("ImportProfileController::Create Name cant be empty.")
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if err := imports.ValidateCSVProfile(profile); err != nil {
+		log.Infof("ImportProfileController::Create Invalid profile: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	count := 0
+	if q := db.DB.Model(&models.ImportProfile{}).Where("name = ?", profile.Name).Count(&count); q.Error != nil {
+		log.Errorf("ImportProfileController::Create Count failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	if count > 0 {
+		log.Infof("ImportProfileController::Create Profile '%s' already exists.", profile.Name)
+		return ctx.NoContent(http.StatusConflict)
+	}
+
+	if q := db.DB.Create(profile); q.Error != nil {
+		log.Errorf("ImportProfileController::Create Create failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("ImportProfileController::Create Profile created: %+v.", profile)
+	return ctx.JSON(http.StatusCreated, TransformImportProfile(profile)[0])
+}
+
+func (c *importProfileController) Update(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("ImportProfileController::Update Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	profile := &models.ImportProfile{}
+	if q := db.DB.First(profile, "id = ?", id); q.Error != nil {
+		if q.RecordNotFound() {
+			log.Infof("ImportProfileController::Update Profile '%d' not found.", id)
+			return ctx.NoContent(http.StatusNotFound)
+		}
+
+		log.Errorf("ImportProfileController::Update First failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	// Start from the current values so only the given fields change.
+	params := &importProfileParams{
+		Name:               profile.Name,
+		Delimiter:          profile.Delimiter,
+		SkipRows:           profile.SkipRows,
+		DateColumn:         profile.DateColumn,
+		DateFormat:         profile.DateFormat,
+		AmountColumn:       profile.AmountColumn,
+		DecimalSeparator:   profile.DecimalSeparator,
+		ThousandsSeparator: profile.ThousandsSeparator,
+		DescriptionColumn:  profile.DescriptionColumn,
+		PayeeColumn:        profile.PayeeColumn,
+	}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("ImportProfileController::Update Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	params.apply(profile)
+
+	if len(profile.Name) == 0 {
+		log.Infof("ImportProfileController::Update Name cant be empty.")
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if err := imports.ValidateCSVProfile(profile); err != nil {
+		log.Infof("ImportProfileController::Update Invalid profile: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	count := 0
+	if q := db.DB.Model(&models.ImportProfile{}).Where("name = ? AND id <> ?", profile.Name, profile.ID).Count(&count); q.Error != nil {
+		log.Errorf("ImportProfileController::Update Count failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	if count > 0 {
+		log.Infof("ImportProfileController::Update Profile '%s' already exists.", profile.Name)
+		return ctx.NoContent(http.StatusConflict)
+	}
+
+	if q := db.DB.Save(profile); q.Error != nil {
+		log.Errorf("ImportProfileController::Update Update failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("ImportProfileController::Update Updated: %+v.", profile)
+	return ctx.JSON(http.StatusOK, TransformImportProfile(profile)[0])
+}
+
+func (c *importProfileController) Delete(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("ImportProfileController::Delete Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	// Profiles are removed for good so their name can be reused.
+	q := db.DB.Unscoped().Where("id = ?", id).Delete(&models.ImportProfile{})
+	if q.Error != nil {
+		log.Errorf("ImportProfileController::Delete Delete failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	if q.RowsAffected == 0 {
+		log.Infof("ImportProfileController::Delete Could not delete profile `%d`. Does not exist.", id)
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	log.Infof("ImportProfileController::Delete Profile '%d' deleted.", id)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// ImportProfileController for /imports/profiles endpoint.
+var ImportProfileController importProfileController
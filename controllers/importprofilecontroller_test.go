@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestImportProfileControllerUpdate(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		profile := &models.ImportProfile{
+			Name:              "bank",
+			Delimiter:         ";",
+			DateColumn:        1,
+			DateFormat:        "dd/mm/yyyy",
+			AmountColumn:      2,
+			DecimalSeparator:  ",",
+			DescriptionColumn: 3,
+			PayeeColumn:       4,
+		}
+		db.DB.Create(profile)
+
+		Convey("Renaming a profile keeps its columns.", t, func() {
+			r := httptest.NewRequest("POST", "/api/imports/profiles/:id", strings.NewReader(`{"name": "my bank"}`))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			c := e.NewContext(r, w)
+			c.SetParamNames("id")
+			c.SetParamValues(strconv.Itoa(int(profile.ID)))
+			So(ImportProfileController.Update(c), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			updated := &models.ImportProfile{}
+			So(db.DB.First(updated, profile.ID).Error, ShouldBeNil)
+			So(updated.Name, ShouldEqual, "my bank")
+			So(updated.Delimiter, ShouldEqual, ";")
+			So(updated.DescriptionColumn, ShouldEqual, 3)
+			So(updated.PayeeColumn, ShouldEqual, 4)
+		})
+	})
+}
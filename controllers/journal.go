@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/imports"
+	"github.com/trtstm/budgetr/models"
+)
+
+// journalCurrency is the commodity of all amounts in a journal.
+const journalCurrency = "EUR"
+
+// journalFormats are the plain text accounting formats, with the date layout and file name of each.
+var journalFormats = map[string]struct {
+	DateLayout string
+	Filename   string
+}{
+	"ledger":    {"2006/01/02", "export.ledger"},
+	"hledger":   {"2006-01-02", "export.journal"},
+	"beancount": {"2006-01-02", "export.beancount"},
+}
+
+// errUnknownJournalFormat is returned for journal formats that are not supported.
+var errUnknownJournalFormat = errors.New("unknown journal format")
+
+var (
+	beancountInvalidRegexp = regexp.MustCompile(`[^A-Za-z0-9-]+`)
+	journalTagRegexp       = regexp.MustCompile(`[^A-Za-z0-9\-_/.]+`)
+)
+
+// journalAccountPart makes name usable as a part of an account name in format.
+func journalAccountPart(format string, name string) string {
+	if format == "beancount" {
+		// Every part has to start with a capital or a digit.
+		part := strings.Trim(beancountInvalidRegexp.ReplaceAllString(name, "-"), "-")
+		if part == "" {
+			return "X"
+		}
+		return strings.ToUpper(part[:1]) + part[1:]
+	}
+
+	// Colons separate the parts and two spaces end the account name.
+	return strings.Join(strings.Fields(strings.Replace(name, ":", "-", -1)), " ")
+}
+
+// journalCategoryAccount returns the account of category id, like Expenses:Food:Groceries.
+func journalCategoryAccount(format string, direction models.Direction, categories map[uint]*models.Category, id uint) string {
+	root := "Expenses"
+	if direction == models.DirectionIncome {
+		root = "Income"
+	}
+
+	category, ok := categories[id]
+	if !ok {
+		return root + ":" + imports.JournalUncategorized
+	}
+
+	parts := []string{journalAccountPart(format, category.Name)}
+	for _, ancestor := range categoryAncestors(categories, id) {
+		parts = append([]string{journalAccountPart(format, categories[ancestor].Name)}, parts...)
+	}
+
+	return root + ":" + strings.Join(parts, ":")
+}
+
+// journalAssetAccount returns the account of a budgetr account, credit cards are liabilities.
+func journalAssetAccount(format string, account *models.Account) string {
+	if account.Type == models.AccountCreditCard {
+		return "Liabilities:" + journalAccountPart(format, account.Name)
+	}
+
+	return "Assets:" + journalAccountPart(format, account.Name)
+}
+
+// journalEntry is a transaction of a journal.
+type journalEntry struct {
+	Date        time.Time
+	Payee       string
+	Description string
+	Reference   string
+	Tags        []string
+	Accounts    []string
+	Amounts     []float64
+}
+
+func (e *journalEntry) post(account string, amount float64) {
+	e.Accounts = append(e.Accounts, account)
+	e.Amounts = append(e.Amounts, amount)
+}
+
+// journalEntries returns the expenditures, income and transfers in [start, end)
+// as journal entries ordered by date. A zero start returns everything.
+func journalEntries(format string, start time.Time, end time.Time) ([]*journalEntry, error) {
+	categories, err := loadCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []*models.Account{}
+	if q := db.DB.Find(&accounts); q.Error != nil {
+		return nil, q.Error
+	}
+	assets := map[uint]string{}
+	for _, account := range accounts {
+		assets[account.ID] = journalAssetAccount(format, account)
+	}
+
+	expenditures := []*models.Expenditure{}
+	q := preloadExpenditures(db.DB).Order("date asc, id asc")
+	if !start.IsZero() {
+		q = dateRangeQuery(start, end, q)
+	}
+	if q = q.Find(&expenditures); q.Error != nil {
+		return nil, q.Error
+	}
+
+	transfers := []*models.Transfer{}
+	q = db.DB.Order("date asc, id asc")
+	if !start.IsZero() {
+		q = dateRangeQuery(start, end, q)
+	}
+	if q = q.Find(&transfers); q.Error != nil {
+		return nil, q.Error
+	}
+
+	entries := []*journalEntry{}
+	for _, e := range expenditures {
+		entry := &journalEntry{Date: e.Date, Payee: e.Payee, Description: e.Description, Reference: e.Reference}
+		for _, tag := range e.Tags {
+			entry.Tags = append(entry.Tags, tag.Name)
+		}
+
+		// Money spent goes to the expense account, income comes from the income account.
+		sign := 1.0
+		if e.Direction == models.DirectionIncome {
+			sign = -1
+		}
+
+		if len(e.Splits) > 0 {
+			for _, split := range e.Splits {
+				entry.post(journalCategoryAccount(format, e.Direction, categories, split.CategoryID), sign*split.Amount)
+			}
+		} else {
+			entry.post(journalCategoryAccount(format, e.Direction, categories, e.CategoryID), sign*e.Amount)
+		}
+		entry.post(assets[e.AccountID], -sign*e.Amount)
+
+		entries = append(entries, entry)
+	}
+
+	for _, t := range transfers {
+		entry := &journalEntry{Date: t.Date, Description: "Transfer"}
+		entry.post(assets[t.ToAccountID], t.Amount)
+		entry.post(assets[t.FromAccountID], -t.Amount)
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+
+	return entries, nil
+}
+
+// beancountQuote returns value as a beancount string.
+func beancountQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// journalTag makes a tag name usable in a journal.
+func journalTag(tag string) string {
+	return strings.Trim(journalTagRegexp.ReplaceAllString(tag, "-"), "-")
+}
+
+// writeJournal writes the expenditures, income and transfers in [start, end)
+// as a ledger or hledger journal or a beancount file. Categories become
+// Expenses:<Category> and Income:<Category> accounts.
+func writeJournal(w io.Writer, format string, start time.Time, end time.Time) error {
+	layout, ok := journalFormats[format]
+	if !ok {
+		return errUnknownJournalFormat
+	}
+
+	entries, err := journalEntries(format, start, end)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(w)
+	indent := "    "
+
+	if format == "beancount" {
+		indent = "  "
+		fmt.Fprintf(out, "option \"operating_currency\" \"%s\"\n\n", journalCurrency)
+
+		// Accounts have to be opened before they are used.
+		opened := map[string]bool{}
+		names := []string{}
+		for _, entry := range entries {
+			for _, account := range entry.Accounts {
+				if !opened[account] {
+					opened[account] = true
+					names = append(names, account)
+				}
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(out, "%s open %s\n", entries[0].Date.Format(layout.DateLayout), name)
+		}
+		if len(names) > 0 {
+			out.WriteString("\n")
+		}
+	}
+
+	// Semicolons start a comment in ledger.
+	clean := strings.NewReplacer(";", ",", "\n", " ", "\r", " ")
+
+	for _, entry := range entries {
+		tags := []string{}
+		for _, tag := range entry.Tags {
+			if tag = journalTag(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+
+		out.WriteString(entry.Date.Format(layout.DateLayout) + " *")
+		if format == "beancount" {
+			if entry.Payee != "" {
+				out.WriteString(" " + beancountQuote(entry.Payee))
+			}
+			out.WriteString(" " + beancountQuote(entry.Description))
+			for _, tag := range tags {
+				out.WriteString(" #" + tag)
+			}
+			out.WriteString("\n")
+
+			if entry.Reference != "" {
+				fmt.Fprintf(out, "%sreference: %s\n", indent, beancountQuote(entry.Reference))
+			}
+		} else {
+			if entry.Reference != "" {
+				out.WriteString(" (" + strings.NewReplacer("(", "", ")", "").Replace(clean.Replace(entry.Reference)) + ")")
+			}
+
+			text := clean.Replace(entry.Description)
+			if entry.Payee != "" {
+				text = clean.Replace(entry.Payee) + " | " + text
+			}
+			out.WriteString(strings.TrimRight(" "+text, " ") + "\n")
+
+			if len(tags) > 0 {
+				if format == "ledger" {
+					fmt.Fprintf(out, "%s; :%s:\n", indent, strings.Join(tags, ":"))
+				} else {
+					fmt.Fprintf(out, "%s; %s:\n", indent, strings.Join(tags, ":, "))
+				}
+			}
+		}
+
+		for i, account := range entry.Accounts {
+			fmt.Fprintf(out, "%s%-40s  %.2f %s\n", indent, account, entry.Amounts[i], journalCurrency)
+		}
+		out.WriteString("\n")
+	}
+
+	return out.Flush()
+}
+
+// matchJournalName returns the name in names that name was exported as, or name itself.
+// Account parts in a journal can differ from the original name, like Eating-out for eating out.
+func matchJournalName(name string, names []string) string {
+	for _, candidate := range names {
+		if strings.EqualFold(candidate, name) {
+			return candidate
+		}
+	}
+
+	for _, candidate := range names {
+		if journalAccountPart("beancount", candidate) == name || journalAccountPart("ledger", candidate) == name {
+			return candidate
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// setupJournalData creates a categorized expense with splits and tags, an income and a transfer.
+func setupJournalData() {
+	food := &models.Category{Name: "food"}
+	db.DB.Create(food)
+	db.DB.Create(&models.Category{Name: "eating out", ParentID: food.ID})
+
+	savings := &models.Account{Name: "Savings", Type: models.AccountSavings}
+	db.DB.Create(savings)
+
+	date := time.Date(2017, 3, 5, 0, 0, 0, 0, time.Local)
+	_, _, err := createExpenditure(models.DirectionExpense, &expenditureParams{
+		Date: date, Amount: 60, Payee: "Colruyt", Description: "Groceries; and dinner", Tags: []string{"kids"}, Reference: "REF-1",
+		Splits: []*splitParams{{Category: "food", Amount: 40}, {Category: "eating out", Amount: 20}},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	if _, _, err := createExpenditure(models.DirectionIncome, &expenditureParams{Date: date.AddDate(0, 0, 1), Amount: 2500, Description: "Salary", Category: "salary"}); err != nil {
+		panic(err)
+	}
+
+	account, _ := findAccount(0)
+	db.DB.Create(&models.Transfer{Amount: 100, Date: date.AddDate(0, 0, 2), FromAccountID: account.ID, ToAccountID: savings.ID})
+}
+
+func TestJournalExport(t *testing.T) {
+	journals := map[string]string{}
+
+	withDb(func() {
+		setupJournalData()
+
+		Convey("Exporting journals.", t, func() {
+			for _, format := range []string{"ledger", "hledger", "beancount"} {
+				buf := &bytes.Buffer{}
+				So(writeJournal(buf, format, time.Time{}, time.Time{}), ShouldBeNil)
+				journals[format] = buf.String()
+			}
+
+			So(journals["ledger"], ShouldContainSubstring, "2017/03/05 * (REF-1) Colruyt | Groceries, and dinner\n    ; :kids:\n")
+			So(journals["ledger"], ShouldContainSubstring, "    Expenses:food:eating out")
+			So(journals["ledger"], ShouldContainSubstring, "    Assets:Default                            -60.00 EUR\n")
+			So(journals["ledger"], ShouldContainSubstring, "    Income:salary                             -2500.00 EUR\n")
+			So(journals["ledger"], ShouldContainSubstring, "2017/03/07 * Transfer\n    Assets:Savings ")
+
+			So(journals["hledger"], ShouldContainSubstring, "2017-03-05 * (REF-1) Colruyt | Groceries, and dinner\n    ; kids:\n")
+
+			So(journals["beancount"], ShouldStartWith, "option \"operating_currency\" \"EUR\"\n\n2017-03-05 open Assets:Default\n")
+			So(journals["beancount"], ShouldContainSubstring, "2017-03-05 open Expenses:Food:Eating-out\n")
+			So(journals["beancount"], ShouldContainSubstring, "2017-03-05 * \"Colruyt\" \"Groceries; and dinner\" #kids\n  reference: \"REF-1\"\n")
+		})
+
+		Convey("Unknown formats are rejected.", t, func() {
+			So(writeJournal(&bytes.Buffer{}, "gnucash", time.Time{}, time.Time{}), ShouldEqual, errUnknownJournalFormat)
+		})
+	})
+
+	for _, format := range []string{"ledger", "beancount"} {
+		withDb(func() {
+			// The categories and accounts already exist with their original names.
+			db.DB.Create(&models.Category{Name: "eating out"})
+			db.DB.Create(&models.Category{Name: "salary"})
+			db.DB.Create(&models.Account{Name: "Savings", Type: models.AccountSavings})
+
+			Convey("Importing an exported "+format+" file.", t, func() {
+				result, err := ImportStatement(strings.NewReader(journals[format]), format, ImportOptions{})
+				So(err, ShouldBeNil)
+				So(result.Created, ShouldEqual, 2)
+				So(result.Skipped, ShouldEqual, 0)
+
+				expense := result.Expenditures[0]
+				So(expense.Amount, ShouldEqual, 60)
+				So(expense.Payee, ShouldEqual, "Colruyt")
+				So(len(expense.Tags), ShouldEqual, 1)
+				So(len(expense.Splits), ShouldEqual, 2)
+				So(expense.Splits[1].Category.Name, ShouldEqual, "eating out")
+				So(expense.Splits[1].Amount, ShouldEqual, 20)
+
+				income := result.Expenditures[1]
+				So(income.Direction, ShouldEqual, models.DirectionIncome)
+				So(income.Category.Name, ShouldEqual, "salary")
+
+				result, err = ImportStatement(strings.NewReader(journals[format]), format, ImportOptions{Duplicates: DuplicatesReject})
+				So(err, ShouldBeNil)
+				So(result.Created, ShouldEqual, 0)
+				So(result.Skipped, ShouldEqual, 2)
+			})
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// defaultExportLocale is used when an export does not ask for a locale.
+const defaultExportLocale = "nl-BE"
+
+// errUnknownLocale is returned when an export asks for a locale that is not in exportLocales.
+var errUnknownLocale = errors.New("unknown locale")
+
+// exportCatalog holds the labels of the exports per language.
+var exportCatalog = map[string]map[string]string{
+	"nl": {
+		"expenses":      "Uitgaves",
+		"transactions":  "Transacties",
+		"category":      "Categorie",
+		"total":         "Totaal",
+		"uncategorized": "geen",
+		"period":        "Periode",
+		"date":          "Datum",
+		"payee":         "Begunstigde",
+		"description":   "Omschrijving",
+		"account":       "Rekening",
+		"amount":        "Bedrag",
+	},
+	"en": {
+		"expenses":      "Expenses",
+		"transactions":  "Transactions",
+		"category":      "Category",
+		"total":         "Total",
+		"uncategorized": "none",
+		"period":        "Period",
+		"date":          "Date",
+		"payee":         "Payee",
+		"description":   "Description",
+		"account":       "Account",
+		"amount":        "Amount",
+	},
+	"fr": {
+		"expenses":      "Dépenses",
+		"transactions":  "Transactions",
+		"category":      "Catégorie",
+		"total":         "Total",
+		"uncategorized": "aucune",
+		"period":        "Période",
+		"date":          "Date",
+		"payee":         "Bénéficiaire",
+		"description":   "Description",
+		"account":       "Compte",
+		"amount":        "Montant",
+	},
+}
+
+// exportLocale describes the labels, dates and numbers of an export.
+type exportLocale struct {
+	Name     string
+	Language string
+	Country  string
+	// DateOrder is the order of day, month and year in dates, like "dmy".
+	DateOrder     string
+	DateSeparator string
+	Decimal       string
+	Thousands     string
+	// CurrencyFirst puts the euro sign before the amount instead of after it.
+	CurrencyFirst bool
+	// CSVDelimiter separates CSV fields. Locales with a decimal comma use `;`
+	// like their spreadsheet programs do.
+	CSVDelimiter rune
+}
+
+// exportLocales are the supported locales by name.
+var exportLocales = map[string]*exportLocale{
+	"nl-BE": {Name: "nl-BE", Language: "nl", Country: "BE", DateOrder: "dmy", DateSeparator: "/", Decimal: ",", Thousands: ".", CurrencyFirst: true, CSVDelimiter: ';'},
+	"nl":    {Name: "nl", Language: "nl", Country: "NL", DateOrder: "dmy", DateSeparator: "-", Decimal: ",", Thousands: ".", CurrencyFirst: true, CSVDelimiter: ';'},
+	"fr-BE": {Name: "fr-BE", Language: "fr", Country: "BE", DateOrder: "dmy", DateSeparator: "/", Decimal: ",", Thousands: "\u00a0", CSVDelimiter: ';'},
+	"fr":    {Name: "fr", Language: "fr", Country: "FR", DateOrder: "dmy", DateSeparator: "/", Decimal: ",", Thousands: "\u00a0", CSVDelimiter: ';'},
+	"en-GB": {Name: "en-GB", Language: "en", Country: "GB", DateOrder: "dmy", DateSeparator: "/", Decimal: ".", Thousands: ",", CurrencyFirst: true, CSVDelimiter: ','},
+	"en":    {Name: "en", Language: "en", Country: "US", DateOrder: "mdy", DateSeparator: "/", Decimal: ".", Thousands: ",", CurrencyFirst: true, CSVDelimiter: ','},
+}
+
+// findExportLocale returns the locale called name, like "nl-BE" or "fr_FR".
+// Unknown regions fall back to their language, the empty name to defaultExportLocale.
+func findExportLocale(name string) (*exportLocale, error) {
+	name = strings.Replace(strings.TrimSpace(name), "_", "-", -1)
+	if name == "" {
+		name = defaultExportLocale
+	}
+
+	for key, locale := range exportLocales {
+		if strings.EqualFold(key, name) {
+			return locale, nil
+		}
+	}
+
+	language := strings.ToLower(strings.SplitN(name, "-", 2)[0])
+	if locale, ok := exportLocales[language]; ok {
+		return locale, nil
+	}
+
+	return nil, errUnknownLocale
+}
+
+// message returns the label called key in the language of l.
+func (l *exportLocale) message(key string) string {
+	if message, ok := exportCatalog[l.Language][key]; ok {
+		return message
+	}
+
+	return key
+}
+
+// dateLayout returns the Go layout of dates, like "02/01/2006".
+func (l *exportLocale) dateLayout() string {
+	parts := []string{}
+	for _, c := range l.DateOrder {
+		switch c {
+		case 'd':
+			parts = append(parts, "02")
+		case 'm':
+			parts = append(parts, "01")
+		case 'y':
+			parts = append(parts, "2006")
+		}
+	}
+
+	return strings.Join(parts, l.DateSeparator)
+}
+
+// excelDateFormat returns the spreadsheet number format of dates, like "dd/mm/yyyy".
+func (l *exportLocale) excelDateFormat() string {
+	parts := []string{}
+	for _, c := range l.DateOrder {
+		switch c {
+		case 'd':
+			parts = append(parts, "dd")
+		case 'm':
+			parts = append(parts, "mm")
+		case 'y':
+			parts = append(parts, "yyyy")
+		}
+	}
+
+	return strings.Join(parts, l.DateSeparator)
+}
+
+// excelCurrencyFormat returns the spreadsheet number format of amounts. The
+// separators in number formats are always `,` and `.`, spreadsheet programs
+// show them in the locale of the reader.
+func (l *exportLocale) excelCurrencyFormat() string {
+	if l.CurrencyFirst {
+		return `"€" #,##0.00`
+	}
+
+	return `#,##0.00 "€"`
+}
+
+// formatNumber writes amount with two decimals and the decimal separator of
+// l, with thousands separators when grouped.
+func (l *exportLocale) formatNumber(amount float64, grouped bool) string {
+	text := strconv.FormatFloat(amount, 'f', 2, 64)
+
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+
+	integer, fraction := text[:len(text)-3], text[len(text)-2:]
+	if grouped {
+		groups := []string{}
+		for len(integer) > 3 {
+			groups = append([]string{integer[len(integer)-3:]}, groups...)
+			integer = integer[:len(integer)-3]
+		}
+		integer = strings.Join(append([]string{integer}, groups...), l.Thousands)
+	}
+
+	return sign + integer + l.Decimal + fraction
+}
+
+// formatCurrency writes amount as it is shown in a spreadsheet, like "€ 1.234,50".
+func (l *exportLocale) formatCurrency(amount float64) string {
+	if l.CurrencyFirst {
+		return "€ " + l.formatNumber(amount, true)
+	}
+
+	return l.formatNumber(amount, true) + " €"
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExportLocales(t *testing.T) {
+	Convey("Finding locales.", t, func() {
+		locale, err := findExportLocale("")
+		So(err, ShouldBeNil)
+		So(locale.Name, ShouldEqual, "nl-BE")
+
+		locale, err = findExportLocale("fr_be")
+		So(err, ShouldBeNil)
+		So(locale.Name, ShouldEqual, "fr-BE")
+
+		locale, err = findExportLocale("en-US")
+		So(err, ShouldBeNil)
+		So(locale.Name, ShouldEqual, "en")
+
+		_, err = findExportLocale("de")
+		So(err, ShouldEqual, errUnknownLocale)
+	})
+
+	Convey("Every language has every label.", t, func() {
+		for _, messages := range exportCatalog {
+			So(len(messages), ShouldEqual, len(exportCatalog["nl"]))
+			for key := range exportCatalog["nl"] {
+				_, ok := messages[key]
+				So(ok, ShouldBeTrue)
+			}
+		}
+	})
+
+	Convey("Formatting dates and numbers.", t, func() {
+		So(exportLocales["nl-BE"].dateLayout(), ShouldEqual, "02/01/2006")
+		So(exportLocales["nl"].excelDateFormat(), ShouldEqual, "dd-mm-yyyy")
+		So(exportLocales["en"].dateLayout(), ShouldEqual, "01/02/2006")
+
+		So(exportLocales["nl-BE"].formatNumber(-1234567.5, false), ShouldEqual, "-1234567,50")
+		So(exportLocales["nl-BE"].formatCurrency(-1234567.5), ShouldEqual, "€ -1.234.567,50")
+		So(exportLocales["fr"].formatCurrency(1234.5), ShouldEqual, "1\u00a0234,50 €")
+		So(exportLocales["en"].formatCurrency(999), ShouldEqual, "€ 999.00")
+	})
+}
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+)
+
+// pivotDimensions are the dimensions a pivot can be grouped by.
+var pivotDimensions = map[string]bool{
+	"category": true,
+	"month":    true,
+	"weekday":  true,
+	"tag":      true,
+	"account":  true,
+	"payee":    true,
+}
+
+// pivotMeasures are the measures a pivot can calculate for every group.
+var pivotMeasures = map[string]func(amounts []float64) float64{
+	"sum": func(amounts []float64) float64 {
+		sum := 0.0
+		for _, amount := range amounts {
+			sum += amount
+		}
+		return sum
+	},
+	"count": func(amounts []float64) float64 {
+		return float64(len(amounts))
+	},
+	"avg": func(amounts []float64) float64 {
+		sum := 0.0
+		for _, amount := range amounts {
+			sum += amount
+		}
+		return sum / float64(len(amounts))
+	},
+	"min": func(amounts []float64) float64 {
+		min := math.Inf(1)
+		for _, amount := range amounts {
+			min = math.Min(min, amount)
+		}
+		return min
+	},
+	"max": func(amounts []float64) float64 {
+		max := math.Inf(-1)
+		for _, amount := range amounts {
+			max = math.Max(max, amount)
+		}
+		return max
+	},
+	"median": func(amounts []float64) float64 {
+		return median(append([]float64{}, amounts...))
+	},
+}
+
+// pivotFact is one amount that is aggregated, an expenditure or one of its splits.
+type pivotFact struct {
+	ID       uint
+	Date     time.Time
+	Category models.NullString
+	Account  models.NullString
+	Payee    string
+	Amount   float64
+}
+
+// pivotFilter limits the expenditures that are aggregated.
+type pivotFilter struct {
+	Direction  models.Direction
+	Start      time.Time
+	End        time.Time
+	Categories []uint
+	Account    uint
+	Tags       []string
+	Payee      string
+	MinAmount  float64
+	MaxAmount  float64
+}
+
+// pivotQuery returns the facts matching filter.
+func pivotQuery(filter *pivotFilter) *gorm.DB {
+	q := categoryAmountsQuery(filter.Direction)
+	q = q.Joins("LEFT JOIN accounts ON accounts.id = expenditures.account_id")
+	q = q.Select("expenditures.id AS id, expenditures.date AS date, categories.name AS category, accounts.name AS account, expenditures.payee AS payee, COALESCE(expenditure_splits.amount, expenditures.amount) AS amount")
+
+	if !filter.Start.IsZero() {
+		q = dateRangeQuery(filter.Start, filter.End, q)
+	}
+	if len(filter.Categories) > 0 {
+		q = q.Where("COALESCE(expenditure_splits.category_id, expenditures.category_id) IN (?)", filter.Categories)
+	}
+	if filter.Account != 0 {
+		q = q.Where("expenditures.account_id = ?", filter.Account)
+	}
+	for _, tag := range filter.Tags {
+		q = q.Where("expenditures.id IN (SELECT expenditure_tags.expenditure_id FROM expenditure_tags JOIN tags ON tags.id = expenditure_tags.tag_id WHERE tags.name = ?)", normalizeTagName(tag))
+	}
+	if len(filter.Payee) > 0 {
+		q = q.Where(`expenditures.payee LIKE ? ESCAPE '\'`, likePattern(filter.Payee))
+	}
+	if filter.MinAmount != 0 {
+		q = q.Where("COALESCE(expenditure_splits.amount, expenditures.amount) >= ?", filter.MinAmount)
+	}
+	if filter.MaxAmount != 0 {
+		q = q.Where("COALESCE(expenditure_splits.amount, expenditures.amount) <= ?", filter.MaxAmount)
+	}
+
+	return q
+}
+
+// pivotKey is the value of a fact for one dimension. Sort orders the
+// values, which for weekdays is not the same as ordering their names.
+type pivotKey struct {
+	Value models.NullString
+	Sort  string
+}
+
+// pivotKeys returns the keys of fact for dimension. A fact with several tags has several keys.
+func pivotKeys(fact *pivotFact, dimension string, tags map[uint][]string) []pivotKey {
+	key := pivotKey{}
+	switch dimension {
+	case "category":
+		key.Value = fact.Category
+	case "account":
+		key.Value = fact.Account
+	case "payee":
+		if payee := strings.TrimSpace(fact.Payee); payee != "" {
+			key.Value.Set(payee)
+		}
+	case "month":
+		key.Value.Set(fact.Date.Format("2006-01"))
+	case "weekday":
+		key.Value.Set(strings.ToLower(fact.Date.Weekday().String()))
+		key.Sort = fmt.Sprint((int(fact.Date.Weekday()) + 6) % 7)
+		return []pivotKey{key}
+	case "tag":
+		keys := []pivotKey{}
+		for _, tag := range tags[fact.ID] {
+			key := pivotKey{Sort: tag}
+			key.Value.Set(tag)
+			keys = append(keys, key)
+		}
+		if len(keys) > 0 {
+			return keys
+		}
+	}
+
+	// Missing values sort last.
+	key.Sort = "\xff"
+	if key.Value.Valid {
+		key.Sort = key.Value.String
+	}
+
+	return []pivotKey{key}
+}
+
+// factTags returns the tag names of the expenditures in facts by expenditure id.
+func factTags(facts []*pivotFact) (map[uint][]string, error) {
+	ids := []uint{}
+	for _, fact := range facts {
+		ids = append(ids, fact.ID)
+	}
+
+	rows := []*struct {
+		ExpenditureID uint
+		Name          string
+	}{}
+	q := db.DB.Table("expenditure_tags").Joins("JOIN tags ON tags.id = expenditure_tags.tag_id")
+	q = q.Select("expenditure_tags.expenditure_id AS expenditure_id, tags.name AS name").Where("expenditure_tags.expenditure_id IN (?)", ids)
+	if q = q.Order("tags.name asc").Scan(&rows); q.Error != nil {
+		return nil, q.Error
+	}
+
+	tags := map[uint][]string{}
+	for _, row := range rows {
+		tags[row.ExpenditureID] = append(tags[row.ExpenditureID], row.Name)
+	}
+
+	return tags, nil
+}
+
+// pivotGroup is one row of a pivot.
+type pivotGroup struct {
+	Keys    []pivotKey
+	Amounts []float64
+}
+
+// pivot aggregates the facts matching filter by dimensions and calculates
+// measures for every group. The groups are ordered by their keys.
+func pivot(filter *pivotFilter, dimensions []string, measures []string) ([]*PivotRowResponse, error) {
+	facts := []*pivotFact{}
+	if q := pivotQuery(filter).Scan(&facts); q.Error != nil {
+		return nil, q.Error
+	}
+
+	tags := map[uint][]string{}
+	for _, dimension := range dimensions {
+		if dimension == "tag" && len(facts) > 0 {
+			var err error
+			if tags, err = factTags(facts); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	groups := []*pivotGroup{}
+	index := map[string]*pivotGroup{}
+	for _, fact := range facts {
+		// Every combination of keys gets the amount, that only matters for tags.
+		combinations := [][]pivotKey{{}}
+		for _, dimension := range dimensions {
+			next := [][]pivotKey{}
+			for _, combination := range combinations {
+				for _, key := range pivotKeys(fact, dimension, tags) {
+					next = append(next, append(append([]pivotKey{}, combination...), key))
+				}
+			}
+			combinations = next
+		}
+
+		for _, keys := range combinations {
+			id := ""
+			for _, key := range keys {
+				id += fmt.Sprintf("%t:%s\x00", key.Value.Valid, key.Value.String)
+			}
+
+			group, ok := index[id]
+			if !ok {
+				group = &pivotGroup{Keys: keys}
+				index[id] = group
+				groups = append(groups, group)
+			}
+			group.Amounts = append(group.Amounts, fact.Amount)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		for k := range dimensions {
+			if groups[i].Keys[k].Sort != groups[j].Keys[k].Sort {
+				return groups[i].Keys[k].Sort < groups[j].Keys[k].Sort
+			}
+		}
+		return false
+	})
+
+	rows := []*PivotRowResponse{}
+	for _, group := range groups {
+		row := &PivotRowResponse{Keys: map[string]models.NullString{}, Values: map[string]float64{}}
+		for k, dimension := range dimensions {
+			row.Keys[dimension] = group.Keys[k].Value
+		}
+		for _, measure := range measures {
+			row.Values[measure] = pivotMeasures[measure](group.Amounts)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
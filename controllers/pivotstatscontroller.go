@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+// PivotRowResponse holds the values of the measures for one combination of dimension keys.
+type PivotRowResponse struct {
+	Keys   map[string]models.NullString `json:"keys"`
+	Values map[string]float64           `json:"values"`
+}
+
+// listParam returns the values of a query parameter that can be repeated or comma separated.
+func listParam(ctx echo.Context, name string) []string {
+	values := []string{}
+	for _, param := range ctx.QueryParams()[name] {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); len(value) > 0 {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
+}
+
+// parsePivotParams parses the dimensions, measures and filters of a pivot query.
+func parsePivotParams(ctx echo.Context) (filter *pivotFilter, dimensions []string, measures []string, err error) {
+	filter = &pivotFilter{}
+	if filter.Start, filter.End, err = parseDateRange(ctx); err != nil {
+		return
+	}
+	if filter.Direction, err = parseDirectionParam(ctx); err != nil {
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, dimension := range listParam(ctx, "group") {
+		if !pivotDimensions[dimension] {
+			return nil, nil, nil, fmt.Errorf("unknown dimension `%s`", dimension)
+		}
+		if !seen[dimension] {
+			seen[dimension] = true
+			dimensions = append(dimensions, dimension)
+		}
+	}
+
+	seen = map[string]bool{}
+	for _, measure := range listParam(ctx, "measure") {
+		if _, ok := pivotMeasures[measure]; !ok {
+			return nil, nil, nil, fmt.Errorf("unknown measure `%s`", measure)
+		}
+		if !seen[measure] {
+			seen[measure] = true
+			measures = append(measures, measure)
+		}
+	}
+	if len(measures) == 0 {
+		measures = []string{"sum"}
+	}
+
+	for _, category := range listParam(ctx, "category") {
+		id, err := strconv.ParseUint(category, 10, 64)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to parse category `%s`: %v", category, err)
+		}
+		filter.Categories = append(filter.Categories, uint(id))
+	}
+
+	if accountQ := ctx.QueryParam("account"); len(accountQ) > 0 {
+		id, err := strconv.ParseUint(accountQ, 10, 64)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to parse account `%s`: %v", accountQ, err)
+		}
+		filter.Account = uint(id)
+	}
+
+	for name, amount := range map[string]*float64{"min_amount": &filter.MinAmount, "max_amount": &filter.MaxAmount} {
+		if amountQ := ctx.QueryParam(name); len(amountQ) > 0 {
+			if *amount, err = strconv.ParseFloat(amountQ, 64); err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to parse %s `%s`: %v", name, amountQ, err)
+			}
+		}
+	}
+
+	filter.Tags = ctx.QueryParams()["tag"]
+	filter.Payee = ctx.QueryParam("payee")
+
+	return filter, dimensions, measures, nil
+}
+
+type pivotStatsController struct {
+}
+
+func (c *pivotStatsController) Index(ctx echo.Context) error {
+	filter, dimensions, measures, err := parsePivotParams(ctx)
+	if err != nil {
+		log.Infof("PivotStatsController::Index %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	rows, err := pivot(filter, dimensions, measures)
+	if err != nil {
+		log.Errorf("PivotStatsController::Index Could not execute query: %v", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.WithFields(log.Fields{"group": dimensions, "measure": measures, "results": len(rows)}).Infof("Returning pivot statistics.")
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data": rows,
+	})
+}
+
+// PivotStatsController for /stats/pivot endpoint.
+var PivotStatsController pivotStatsController
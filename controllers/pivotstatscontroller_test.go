@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPivotStatsControllerIndex(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		create := func(date time.Time, amount float64, category string, payee string, tags ...string) {
+			_, _, err := createExpenditure(models.DirectionExpense, &expenditureParams{Date: date, Amount: amount, Category: category, Payee: payee, Tags: tags})
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		// 2017-03-06 is a monday.
+		create(time.Date(2017, 3, 6, 12, 0, 0, 0, time.UTC), 10, "food", "Colruyt", "kids")
+		create(time.Date(2017, 3, 7, 12, 0, 0, 0, time.UTC), 30, "food", "Delhaize")
+		create(time.Date(2017, 3, 13, 12, 0, 0, 0, time.UTC), 20, "food", "Colruyt", "kids", "holiday")
+		create(time.Date(2017, 4, 2, 12, 0, 0, 0, time.UTC), 700, "rent", "")
+
+		index := func(url string) ([]*PivotRowResponse, int) {
+			r := httptest.NewRequest("GET", url, nil)
+			w := httptest.NewRecorder()
+			So(PivotStatsController.Index(e.NewContext(r, w)), ShouldBeNil)
+
+			answer := &struct {
+				Data []*PivotRowResponse `json:"data"`
+			}{}
+			if w.Code == http.StatusOK {
+				So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			}
+			return answer.Data, w.Code
+		}
+
+		Convey("Grouping by category and month.", t, func() {
+			rows, code := index("/api/stats/pivot?group=category,month&measure=sum,count,median")
+			So(code, ShouldEqual, http.StatusOK)
+			So(len(rows), ShouldEqual, 2)
+			So(rows[0].Keys["category"].String, ShouldEqual, "food")
+			So(rows[0].Keys["month"].String, ShouldEqual, "2017-03")
+			So(rows[0].Values["sum"], ShouldEqual, 60)
+			So(rows[0].Values["count"], ShouldEqual, 3)
+			So(rows[0].Values["median"], ShouldEqual, 20)
+			So(rows[1].Keys["category"].String, ShouldEqual, "rent")
+		})
+
+		Convey("Grouping by weekday orders the days of the week.", t, func() {
+			rows, code := index("/api/stats/pivot?group=weekday&measure=avg&measure=max")
+			So(code, ShouldEqual, http.StatusOK)
+			So(len(rows), ShouldEqual, 3)
+			So(rows[0].Keys["weekday"].String, ShouldEqual, "monday")
+			So(rows[0].Values["avg"], ShouldEqual, 15)
+			So(rows[2].Keys["weekday"].String, ShouldEqual, "sunday")
+			So(rows[2].Values["max"], ShouldEqual, 700)
+		})
+
+		Convey("Grouping by tag and payee with filters.", t, func() {
+			rows, code := index("/api/stats/pivot?group=tag&start=2017-03-01T00:00:00Z&end=2017-04-01T00:00:00Z")
+			So(code, ShouldEqual, http.StatusOK)
+			So(len(rows), ShouldEqual, 3)
+			So(rows[0].Keys["tag"].String, ShouldEqual, "holiday")
+			So(rows[1].Keys["tag"].String, ShouldEqual, "kids")
+			So(rows[1].Values["sum"], ShouldEqual, 30)
+			So(rows[2].Keys["tag"].Valid, ShouldBeFalse)
+
+			rows, code = index("/api/stats/pivot?group=payee&min_amount=15")
+			So(code, ShouldEqual, http.StatusOK)
+			So(len(rows), ShouldEqual, 3)
+			So(rows[2].Keys["payee"].Valid, ShouldBeFalse)
+
+			rows, code = index("/api/stats/pivot?group=payee&payee=colruyt")
+			So(code, ShouldEqual, http.StatusOK)
+			So(len(rows), ShouldEqual, 1)
+			So(rows[0].Values["sum"], ShouldEqual, 30)
+
+			rows, code = index("/api/stats/pivot?group=payee&payee=col_uyt")
+			So(code, ShouldEqual, http.StatusOK)
+			So(len(rows), ShouldEqual, 0)
+
+			rows, code = index("/api/stats/pivot?tag=kids&measure=min")
+			So(code, ShouldEqual, http.StatusOK)
+			So(len(rows), ShouldEqual, 1)
+			So(rows[0].Values["min"], ShouldEqual, 10)
+		})
+
+		Convey("Unknown dimensions and measures are rejected.", t, func() {
+			for _, url := range []string{"/api/stats/pivot?group=year", "/api/stats/pivot?measure=stddev"} {
+				_, code := index(url)
+				So(code, ShouldEqual, http.StatusBadRequest)
+			}
+		})
+	})
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+// materializeMutex makes sure the scheduler and the API don't create the same occurrence twice.
+var materializeMutex sync.Mutex
+
+// MaterializeRecurring creates the expenditures of the recurring templates
+// that are due at now, including the occurrences that were missed because
+// the server was not running. It returns the number of created expenditures.
+func MaterializeRecurring(now time.Time) (int, error) {
+	materializeMutex.Lock()
+	defer materializeMutex.Unlock()
+
+	recurrings := []*models.Recurring{}
+	if q := db.DB.Preload("Category").Where("paused = ? AND next_date <= ?", false, now).Find(&recurrings); q.Error != nil {
+		return 0, q.Error
+	}
+
+	created := 0
+	var lastErr error
+	for _, recurring := range recurrings {
+		n, err := materializeRecurring(recurring, now)
+		created += n
+		if err != nil {
+			// One broken template should not block the others.
+			log.Errorf("Could not create expenditures for recurring '%d': %v", recurring.ID, err)
+			lastErr = err
+		}
+	}
+
+	return created, lastErr
+}
+
+// materializeRecurring creates the occurrences of recurring up to now.
+func materializeRecurring(recurring *models.Recurring, now time.Time) (int, error) {
+	created := 0
+	for date := recurring.Occurrence(recurring.Count); !date.After(now) && !recurring.Ended(date); date = recurring.Occurrence(recurring.Count) {
+		// The occurrence may already exist when a previous run stopped halfway.
+		count := 0
+		if q := db.DB.Model(&models.Expenditure{}).Unscoped().Where("recurring_id = ? AND date = ?", recurring.ID, date).Count(&count); q.Error != nil {
+			return created, q.Error
+		}
+
+		if count == 0 {
+			params := &expenditureParams{
+				Date:        date,
+				Amount:      recurring.Amount,
+				Account:     recurring.AccountID,
+				Payee:       recurring.Payee,
+				Description: recurring.Description,
+				RecurringID: recurring.ID,
+			}
+			if recurring.Category != nil {
+				params.Category = recurring.Category.Name
+			}
+
+			if _, _, err := createExpenditure(recurring.Direction, params); err != nil {
+				return created, err
+			}
+			created++
+		}
+
+		recurring.Count++
+		recurring.NextDate = recurring.Occurrence(recurring.Count)
+		q := db.DB.Model(recurring).UpdateColumns(map[string]interface{}{
+			"count":     recurring.Count,
+			"next_date": recurring.NextDate,
+		})
+		if q.Error != nil {
+			return created, q.Error
+		}
+	}
+
+	return created, nil
+}
+
+// skipOccurrences returns the number of occurrences of recurring that fall before date.
+func skipOccurrences(recurring *models.Recurring, date time.Time) int {
+	n := 0
+	for recurring.Occurrence(n).Before(date) {
+		n++
+	}
+
+	return n
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+// recurringParams holds the fields used to create or update a recurring template.
+type recurringParams struct {
+	Name        string                `json:"name" form:"name"`
+	Amount      float64               `json:"amount" form:"amount"`
+	Direction   models.Direction      `json:"direction" form:"direction"`
+	Category    string                `json:"category" form:"category"`
+	Account     uint                  `json:"account" form:"account"`
+	Payee       string                `json:"payee" form:"payee"`
+	Description string                `json:"description" form:"description"`
+	Unit        models.RecurrenceUnit `json:"unit" form:"unit"`
+	Interval    int                   `json:"interval" form:"interval"`
+	StartDate   time.Time             `json:"start_date" form:"start_date"`
+	EndDate     time.Time             `json:"end_date" form:"end_date"`
+	Paused      bool                  `json:"paused" form:"paused"`
+}
+
+// recurringParamsFrom returns the params of an existing template so an update only has to send what changes.
+func recurringParamsFrom(recurring *models.Recurring) *recurringParams {
+	params := &recurringParams{
+		Name:        recurring.Name,
+		Amount:      recurring.Amount,
+		Direction:   recurring.Direction,
+		Account:     recurring.AccountID,
+		Payee:       recurring.Payee,
+		Description: recurring.Description,
+		Unit:        recurring.Unit,
+		Interval:    recurring.Interval,
+		StartDate:   recurring.StartDate,
+		EndDate:     recurring.EndDate,
+		Paused:      recurring.Paused,
+	}
+
+	if recurring.Category != nil {
+		params.Category = recurring.Category.Name
+	}
+
+	return params
+}
+
+// saveRecurring validates params, copies them into recurring and saves it.
+// The returned status is http.StatusOK when everything went fine.
+func saveRecurring(action string, recurring *models.Recurring, params *recurringParams) int {
+	if params.Direction == "" {
+		params.Direction = models.DirectionExpense
+	}
+	if params.Interval == 0 {
+		params.Interval = 1
+	}
+
+	if params.Amount < 0 {
+		log.Infof("RecurringController::%s Amount cant be negative.", action)
+		return http.StatusBadRequest
+	}
+	if params.Direction != models.DirectionExpense && params.Direction != models.DirectionIncome {
+		log.Infof("RecurringController::%s Unknown direction `%s`.", action, params.Direction)
+		return http.StatusBadRequest
+	}
+	if !params.Unit.Valid() || params.Interval < 0 {
+		log.Infof("RecurringController::%s Invalid schedule: every %d %s.", action, params.Interval, params.Unit)
+		return http.StatusBadRequest
+	}
+	if params.StartDate.IsZero() {
+		log.Infof("RecurringController::%s Start date is required.", action)
+		return http.StatusBadRequest
+	}
+	if !params.EndDate.IsZero() && params.EndDate.Before(params.StartDate) {
+		log.Infof("RecurringController::%s End date is before the start date.", action)
+		return http.StatusBadRequest
+	}
+	if params.Account != 0 {
+		if _, err := findAccount(params.Account); err != nil {
+			log.Infof("RecurringController::%s Could not find account '%d': '%v'.", action, params.Account, err)
+			return http.StatusBadRequest
+		}
+	}
+
+	var category *models.Category
+	if name := strings.TrimSpace(params.Category); len(name) != 0 {
+		category = &models.Category{Name: name}
+		if q := db.DB.FirstOrCreate(category, "name = ?", category.Name); q.Error != nil {
+			log.Errorf("RecurringController::%s FirstOrCreate failed: '%v'.", action, q.Error)
+			return http.StatusInternalServerError
+		}
+	}
+
+	scheduleChanged := recurring.Unit != params.Unit || recurring.Interval != params.Interval || !recurring.StartDate.Equal(params.StartDate)
+
+	recurring.Name = strings.TrimSpace(params.Name)
+	recurring.Amount = params.Amount
+	recurring.Direction = params.Direction
+	recurring.Category = category
+	recurring.CategoryID = 0
+	if category != nil {
+		recurring.CategoryID = category.ID
+	}
+	recurring.AccountID = params.Account
+	recurring.Payee = strings.TrimSpace(params.Payee)
+	recurring.Description = strings.TrimSpace(params.Description)
+	recurring.EndDate = params.EndDate
+	recurring.Paused = params.Paused
+
+	if scheduleChanged {
+		// Occurrences before the next one of the old schedule were already created.
+		next := recurring.NextDate
+		recurring.Unit = params.Unit
+		recurring.Interval = params.Interval
+		recurring.StartDate = params.StartDate
+		recurring.Count = 0
+		if recurring.ID != 0 {
+			recurring.Count = skipOccurrences(recurring, next)
+		}
+		recurring.NextDate = recurring.Occurrence(recurring.Count)
+	}
+
+	if q := db.DB.Save(recurring); q.Error != nil {
+		log.Errorf("RecurringController::%s Save failed: '%v'.", action, q.Error)
+		return http.StatusInternalServerError
+	}
+
+	return http.StatusOK
+}
+
+type recurringController struct {
+}
+
+func (c *recurringController) Index(ctx echo.Context) error {
+	recurrings := []*models.Recurring{}
+
+	if q := db.DB.Preload("Category").Order("next_date asc").Find(&recurrings); q.Error != nil {
+		log.Errorf("RecurringController::Index Could not execute find query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("RecurringController::Index Returning %d recurring templates.", len(recurrings))
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data": TransformRecurring(recurrings...),
+	})
+}
+
+func (c *recurringController) Create(ctx echo.Context) error {
+	params := &recurringParams{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("RecurringController::Create Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	recurring := &models.Recurring{}
+	if status := saveRecurring("Create", recurring, params); status != http.StatusOK {
+		return ctx.NoContent(status)
+	}
+
+	log.Infof("RecurringController::Create Recurring template created: %+v.", recurring)
+	return ctx.JSON(http.StatusCreated, TransformRecurring(recurring)[0])
+}
+
+func (c *recurringController) Update(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("RecurringController::Update Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	recurring := &models.Recurring{}
+	if q := db.DB.Preload("Category").First(recurring, "id = ?", id); q.Error != nil {
+		if q.RecordNotFound() {
+			log.Infof("RecurringController::Update Recurring template '%d' not found.", id)
+			return ctx.NoContent(http.StatusNotFound)
+		}
+
+		log.Errorf("RecurringController::Update First failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	// Fields that are not sent keep their current value.
+	params := recurringParamsFrom(recurring)
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("RecurringController::Update Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if status := saveRecurring("Update", recurring, params); status != http.StatusOK {
+		return ctx.NoContent(status)
+	}
+
+	log.Infof("RecurringController::Update Updated: %+v.", recurring)
+	return ctx.JSON(http.StatusOK, TransformRecurring(recurring)[0])
+}
+
+func (c *recurringController) Delete(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("RecurringController::Delete Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	q := db.DB.Where("id = ?", id).Delete(&models.Recurring{})
+	if q.Error != nil {
+		log.Errorf("RecurringController::Delete Delete failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	if q.RowsAffected == 0 {
+		log.Infof("RecurringController::Delete Could not delete recurring template `%d`. Does not exist.", id)
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	log.Infof("RecurringController::Delete Recurring template '%d' deleted.", id)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Run creates the expenditures that are due now instead of waiting for the scheduler.
+func (c *recurringController) Run(ctx echo.Context) error {
+	created, err := MaterializeRecurring(time.Now())
+	if err != nil {
+		log.Errorf("RecurringController::Run Could not create all expenditures: '%v'.", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("RecurringController::Run Created %d expenditures.", created)
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"created": created,
+	})
+}
+
+// RecurringController for /recurring endpoint.
+var RecurringController recurringController
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecurringOccurrence(t *testing.T) {
+	Convey("Monthly schedules stay on the same day of the month.", t, func() {
+		r := &models.Recurring{Unit: models.RecurrenceMonthly, Interval: 1, StartDate: time.Date(2017, 1, 31, 9, 0, 0, 0, time.Local)}
+		So(r.Occurrence(1).Equal(time.Date(2017, 2, 28, 9, 0, 0, 0, time.Local)), ShouldBeTrue)
+		So(r.Occurrence(2).Equal(time.Date(2017, 3, 31, 9, 0, 0, 0, time.Local)), ShouldBeTrue)
+	})
+
+	Convey("Weekly and yearly schedules.", t, func() {
+		r := &models.Recurring{Unit: models.RecurrenceWeekly, Interval: 2, StartDate: time.Date(2017, 1, 2, 0, 0, 0, 0, time.Local)}
+		So(r.Occurrence(3).Equal(time.Date(2017, 2, 13, 0, 0, 0, 0, time.Local)), ShouldBeTrue)
+
+		r = &models.Recurring{Unit: models.RecurrenceYearly, Interval: 1, StartDate: time.Date(2016, 2, 29, 0, 0, 0, 0, time.Local)}
+		So(r.Occurrence(1).Equal(time.Date(2017, 2, 28, 0, 0, 0, 0, time.Local)), ShouldBeTrue)
+	})
+}
+
+func TestRecurringController(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		recurring := &RecurringResponse{}
+
+		Convey("Creating a recurring template.", t, func() {
+			r := httptest.NewRequest("POST", "/api/recurring", strings.NewReader(`{"name": "Rent", "amount": 750, "category": "housing", "payee": "Landlord", "unit": "month", "start_date": "2017-01-05T00:00:00Z"}`))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			So(RecurringController.Create(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusCreated)
+
+			So(json.NewDecoder(w.Result().Body).Decode(recurring), ShouldBeNil)
+			So(recurring.Interval, ShouldEqual, 1)
+			So(recurring.Category.Name, ShouldEqual, "housing")
+			So(recurring.NextDate.Equal(time.Date(2017, 1, 5, 0, 0, 0, 0, time.UTC)), ShouldBeTrue)
+		})
+
+		Convey("Invalid schedules are rejected.", t, func() {
+			r := httptest.NewRequest("POST", "/api/recurring", strings.NewReader(`{"amount": 10, "unit": "fortnight", "start_date": "2017-01-05T00:00:00Z"}`))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			So(RecurringController.Create(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Catching up on missed occurrences.", t, func() {
+			now := time.Date(2017, 4, 10, 0, 0, 0, 0, time.UTC)
+			created, err := MaterializeRecurring(now)
+			So(err, ShouldBeNil)
+			So(created, ShouldEqual, 4)
+
+			expenditures := []*models.Expenditure{}
+			db.DB.Preload("Category").Where("recurring_id = ?", recurring.ID).Order("date asc").Find(&expenditures)
+			So(len(expenditures), ShouldEqual, 4)
+			So(expenditures[3].Date.Equal(time.Date(2017, 4, 5, 0, 0, 0, 0, time.UTC)), ShouldBeTrue)
+			So(expenditures[0].Payee, ShouldEqual, "Landlord")
+			So(expenditures[0].Category.Name, ShouldEqual, "housing")
+
+			created, err = MaterializeRecurring(now)
+			So(err, ShouldBeNil)
+			So(created, ShouldEqual, 0)
+		})
+
+		Convey("Changing the schedule keeps the created occurrences.", t, func() {
+			r := httptest.NewRequest("POST", "/api/recurring/:id", strings.NewReader(`{"interval": 2}`))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			c := e.NewContext(r, w)
+			c.SetParamNames("id")
+			c.SetParamValues(strconv.Itoa(int(recurring.ID)))
+			So(RecurringController.Update(c), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			answer := &RecurringResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(answer.NextDate.Equal(time.Date(2017, 5, 5, 0, 0, 0, 0, time.UTC)), ShouldBeTrue)
+		})
+
+		Convey("Paused templates are skipped.", t, func() {
+			db.DB.Model(&models.Recurring{}).Where("id = ?", recurring.ID).UpdateColumn("paused", true)
+
+			created, err := MaterializeRecurring(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+			So(err, ShouldBeNil)
+			So(created, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestRecurringLegacyExpenditures(t *testing.T) {
+	withDb(func() {
+		expenditure := &models.Expenditure{Amount: 10, Date: time.Now()}
+		db.DB.Create(expenditure)
+		deleted := &models.Expenditure{Amount: 20, Date: time.Now()}
+		db.DB.Create(deleted)
+		db.DB.Delete(deleted)
+
+		// Expenditures from before recurring templates existed got a NULL recurring_id.
+		db.DB.Exec("UPDATE expenditures SET recurring_id = NULL")
+
+		Convey("Upgrading the schema clears the NULL recurring ids.", t, func() {
+			So(db.SetupSchema(), ShouldBeNil)
+
+			count := 0
+			db.DB.Unscoped().Model(&models.Expenditure{}).Where("recurring_id = 0").Count(&count)
+			So(count, ShouldEqual, 2)
+
+			loaded := &models.Expenditure{}
+			So(db.DB.First(loaded, expenditure.ID).Error, ShouldBeNil)
+			So(loaded.RecurringID, ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+)
+
+// errInvalidSplits is returned when the splits of an expenditure don't add up to its amount.
+var errInvalidSplits = errors.New("splits should add up to the amount")
+
+// splitParams holds the fields of a split line.
+type splitParams struct {
+	Category string  `json:"category" form:"category"`
+	Amount   float64 `json:"amount" form:"amount"`
+}
+
+// preloadExpenditures loads everything TransformExpenditure returns.
+func preloadExpenditures(q *gorm.DB) *gorm.DB {
+	return q.Preload("Category").Preload("Account").Preload("Tags").Preload("Splits.Category")
+}
+
+// buildSplits validates the split lines against amount. The splits and their
+// categories are not stored yet, see resolveSplitCategories.
+func buildSplits(params []*splitParams, amount float64) ([]*models.ExpenditureSplit, error) {
+	splits := []*models.ExpenditureSplit{}
+	if len(params) == 0 {
+		return splits, nil
+	}
+
+	sum := 0.0
+	for _, param := range params {
+		if param.Amount <= 0 {
+			return nil, errInvalidSplits
+		}
+		sum += param.Amount
+	}
+	if !splitsMatch(sum, amount) {
+		return nil, errInvalidSplits
+	}
+
+	for _, param := range params {
+		split := &models.ExpenditureSplit{Amount: param.Amount}
+
+		if name := strings.TrimSpace(param.Category); len(name) != 0 {
+			split.Category = &models.Category{Name: name}
+		}
+
+		splits = append(splits, split)
+	}
+
+	return splits, nil
+}
+
+// resolveSplitCategories finds or creates the categories of splits in tx, so
+// they are only created when the splits are stored.
+func resolveSplitCategories(tx *gorm.DB, splits []*models.ExpenditureSplit) error {
+	for _, split := range splits {
+		if split.Category == nil || split.Category.ID != 0 {
+			continue
+		}
+
+		if q := tx.FirstOrCreate(split.Category, "name = ?", split.Category.Name); q.Error != nil {
+			return q.Error
+		}
+		split.CategoryID = split.Category.ID
+	}
+
+	return nil
+}
+
+// splitsMatch returns whether the sum of the splits equals amount, up to a cent.
+func splitsMatch(sum float64, amount float64) bool {
+	return math.Abs(sum-amount) < 0.005
+}
+
+// replaceSplits removes the current splits of e and stores splits instead.
+func replaceSplits(e *models.Expenditure, splits []*models.ExpenditureSplit) error {
+	tx := db.DB.Begin()
+	if q := tx.Unscoped().Where("expenditure_id = ?", e.ID).Delete(&models.ExpenditureSplit{}); q.Error != nil {
+		tx.Rollback()
+		return q.Error
+	}
+
+	if err := resolveSplitCategories(tx, splits); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, split := range splits {
+		split.ExpenditureID = e.ID
+		if q := tx.Create(split); q.Error != nil {
+			tx.Rollback()
+			return q.Error
+		}
+	}
+
+	if q := tx.Commit(); q.Error != nil {
+		return q.Error
+	}
+
+	e.Splits = splits
+	return nil
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSplitExpenditures(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		post := func(endpoint echo.HandlerFunc, id uint, body string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest("POST", "/api/expenditures", strings.NewReader(body))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			c := e.NewContext(r, w)
+			if id != 0 {
+				c.SetParamNames("id")
+				c.SetParamValues(strconv.Itoa(int(id)))
+			}
+			So(endpoint(c), ShouldBeNil)
+			return w
+		}
+
+		categoryTotals := func() map[string]float64 {
+			r := httptest.NewRequest("GET", "/api/stats/categories", nil)
+			w := httptest.NewRecorder()
+			So(CategoryStatsController.Index(e.NewContext(r, w)), ShouldBeNil)
+
+			stats := []*CategoryStatsResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(&stats), ShouldBeNil)
+
+			totals := map[string]float64{}
+			for _, stat := range stats {
+				totals[stat.Name.String] = stat.Total
+			}
+			return totals
+		}
+
+		var receipt *ExpenditureResponse
+		Convey("Creating a split expenditure.", t, func() {
+			w := post(ExpenditureController.Create, 0, `{"amount": 60, "category": "groceries", "splits": [{"category": "groceries", "amount": 35.5}, {"category": "household", "amount": 24.5}]}`)
+			So(w.Code, ShouldEqual, http.StatusCreated)
+
+			receipt = &ExpenditureResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(receipt), ShouldBeNil)
+			So(len(receipt.Splits), ShouldEqual, 2)
+			So(receipt.Splits[1].Category.Name, ShouldEqual, "household")
+
+			w = post(ExpenditureController.Create, 0, `{"amount": 20, "category": "groceries"}`)
+			So(w.Code, ShouldEqual, http.StatusCreated)
+
+			totals := categoryTotals()
+			So(totals["groceries"], ShouldEqual, 55.5)
+			So(totals["household"], ShouldEqual, 24.5)
+		})
+
+		Convey("Splits should add up to the amount.", t, func() {
+			w := post(ExpenditureController.Create, 0, `{"amount": 60, "splits": [{"category": "groceries", "amount": 30}]}`)
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+
+			w = post(ExpenditureController.Update, receipt.ID, `{"amount": 70}`)
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Splits should be positive.", t, func() {
+			w := post(ExpenditureController.Create, 0, `{"amount": 100, "splits": [{"category": "groceries", "amount": 150}, {"category": "household", "amount": -50}]}`)
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Rejected expenditures do not create split categories.", t, func() {
+			w := post(ExpenditureController.Create, 0, `{"amount": 20, "duplicates": "reject", "splits": [{"category": "garden", "amount": 20}]}`)
+			So(w.Code, ShouldEqual, http.StatusConflict)
+
+			count := 0
+			db.DB.Model(&models.Category{}).Where("name = ?", "garden").Count(&count)
+			So(count, ShouldEqual, 0)
+		})
+
+		Convey("Replacing and removing splits.", t, func() {
+			w := post(ExpenditureController.Update, receipt.ID, `{"amount": 70, "splits": [{"category": "household", "amount": 70}]}`)
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(categoryTotals()["household"], ShouldEqual, 70)
+
+			w = post(ExpenditureController.Update, receipt.ID, `{"splits": []}`)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			totals := categoryTotals()
+			So(totals["groceries"], ShouldEqual, 90)
+			So(totals["household"], ShouldEqual, 0)
+		})
+	})
+}
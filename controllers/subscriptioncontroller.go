@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/log"
+)
+
+type subscriptionController struct {
+}
+
+func (c *subscriptionController) Index(ctx echo.Context) error {
+	subscriptions, err := detectSubscriptions(time.Now())
+	if err != nil {
+		log.Errorf("SubscriptionController::Index Could not detect subscriptions: %v", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("SubscriptionController::Index Returning %d subscriptions.", len(subscriptions))
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data": TransformSubscription(subscriptions...),
+	})
+}
+
+// Convert turns a detected subscription into a recurring template.
+func (c *subscriptionController) Convert(ctx echo.Context) error {
+	params := &struct {
+		Payee  string  `json:"payee" form:"payee"`
+		Amount float64 `json:"amount" form:"amount"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("SubscriptionController::Convert Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	now := time.Now()
+	s, err := findSubscription(now, params.Payee, params.Amount)
+	if err != nil {
+		log.Errorf("SubscriptionController::Convert Could not detect subscriptions: %v", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	if s == nil {
+		log.Infof("SubscriptionController::Convert No subscription for `%s` of %.2f.", params.Payee, params.Amount)
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	recurring, err := convertSubscription(s, now)
+	if err == errSubscriptionInactive {
+		log.Infof("SubscriptionController::Convert Subscription `%s` of %.2f is no longer active.", s.Payee, s.Amount)
+		return ctx.NoContent(http.StatusConflict)
+	}
+	if err != nil {
+		log.Errorf("SubscriptionController::Convert Could not create recurring template: %v", err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("SubscriptionController::Convert Recurring template created: %+v.", recurring)
+	return ctx.JSON(http.StatusCreated, TransformRecurring(recurring)[0])
+}
+
+// SubscriptionController for /subscriptions endpoint.
+var SubscriptionController subscriptionController
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSubscriptions(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		create := func(date time.Time, amount float64, payee string) {
+			_, _, err := createExpenditure(models.DirectionExpense, &expenditureParams{Date: date, Amount: amount, Payee: payee, Category: "entertainment"})
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		start := time.Now().AddDate(0, -5, 0)
+		for i := 0; i < 6; i++ {
+			create(start.AddDate(0, i, 0), 12.99, "Netflix")
+		}
+		for i := 0; i < 3; i++ {
+			create(start.AddDate(0, 0, 7*i), 50, "Colruyt")
+			create(start.AddDate(0, 0, 7*i+3), 30+float64(i), "Delhaize")
+		}
+		create(start.AddDate(0, 0, 40), 50, "Colruyt")
+
+		Convey("Detecting subscriptions.", t, func() {
+			r := httptest.NewRequest("GET", "/api/subscriptions", nil)
+			w := httptest.NewRecorder()
+			So(SubscriptionController.Index(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			answer := &struct {
+				Data []*SubscriptionResponse `json:"data"`
+			}{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			So(len(answer.Data), ShouldEqual, 1)
+
+			netflix := answer.Data[0]
+			So(netflix.Payee, ShouldEqual, "Netflix")
+			So(netflix.Unit, ShouldEqual, models.RecurrenceMonthly)
+			So(netflix.Interval, ShouldEqual, 1)
+			So(netflix.Charges, ShouldEqual, 6)
+			So(netflix.AnnualCost, ShouldAlmostEqual, 155.88)
+			So(netflix.Active, ShouldBeTrue)
+			So(netflix.Category.Name, ShouldEqual, "entertainment")
+		})
+
+		Convey("Converting a subscription into a recurring template.", t, func() {
+			r := httptest.NewRequest("POST", "/api/subscriptions/convert", strings.NewReader(`{"payee": "netflix", "amount": 12.99}`))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			So(SubscriptionController.Convert(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusCreated)
+
+			recurring := &RecurringResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(recurring), ShouldBeNil)
+			So(recurring.Unit, ShouldEqual, models.RecurrenceMonthly)
+			So(recurring.NextDate.After(time.Now()), ShouldBeTrue)
+
+			subscriptions, err := detectSubscriptions(time.Now())
+			So(err, ShouldBeNil)
+			So(len(subscriptions), ShouldEqual, 0)
+
+			r = httptest.NewRequest("POST", "/api/subscriptions/convert", strings.NewReader(`{"payee": "netflix", "amount": 12.99}`))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w = httptest.NewRecorder()
+			So(SubscriptionController.Convert(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+	})
+}
+
+func TestSubscriptionConversionSchedule(t *testing.T) {
+	e := echo.New()
+
+	convert := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/api/subscriptions/convert", strings.NewReader(body))
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		w := httptest.NewRecorder()
+		So(SubscriptionController.Convert(e.NewContext(r, w)), ShouldBeNil)
+		return w
+	}
+
+	withDb(func() {
+		create := func(date time.Time, amount float64, payee string) {
+			if _, _, err := createExpenditure(models.DirectionExpense, &expenditureParams{Date: date, Amount: amount, Payee: payee}); err != nil {
+				panic(err)
+			}
+		}
+
+		cancelled := time.Now().AddDate(0, -6, 0)
+		late := time.Now().AddDate(0, 0, -31)
+		for i := 0; i < 4; i++ {
+			create(cancelled.AddDate(0, -i, 0), 30, "Gym")
+			create(late.AddDate(0, -i, 0), 9.99, "Spotify")
+		}
+
+		Convey("Inactive subscriptions can not be converted.", t, func() {
+			So(convert(`{"payee": "gym", "amount": 30}`).Code, ShouldEqual, http.StatusConflict)
+
+			created, err := MaterializeRecurring(time.Now())
+			So(err, ShouldBeNil)
+			So(created, ShouldEqual, 0)
+		})
+
+		Convey("Late charges are left to the bank import.", t, func() {
+			w := convert(`{"payee": "spotify", "amount": 9.99}`)
+			So(w.Code, ShouldEqual, http.StatusCreated)
+
+			recurring := &RecurringResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(recurring), ShouldBeNil)
+			So(recurring.StartDate.After(time.Now()), ShouldBeTrue)
+
+			created, err := MaterializeRecurring(time.Now())
+			So(err, ShouldBeNil)
+			So(created, ShouldEqual, 0)
+		})
+	})
+}
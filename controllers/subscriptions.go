@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+)
+
+// subscriptionCadence is a schedule subscriptions are commonly charged on.
+type subscriptionCadence struct {
+	Unit     models.RecurrenceUnit
+	Interval int
+	// Days is the average number of days between two charges and
+	// Tolerance how many days a charge can be early or late.
+	Days      float64
+	Tolerance float64
+	PerYear   float64
+	// MinCharges is the number of charges needed to recognize the cadence.
+	MinCharges int
+}
+
+var subscriptionCadences = []*subscriptionCadence{
+	{models.RecurrenceWeekly, 1, 7, 1, 52, 4},
+	{models.RecurrenceWeekly, 2, 14, 2, 26, 3},
+	{models.RecurrenceMonthly, 1, 30.44, 4, 12, 3},
+	{models.RecurrenceMonthly, 3, 91.31, 8, 4, 3},
+	{models.RecurrenceYearly, 1, 365.25, 12, 1, 2},
+}
+
+// errSubscriptionInactive is returned when converting a subscription that is no longer charged.
+var errSubscriptionInactive = errors.New("subscription is no longer active")
+
+// subscriptionMinRegularity is the part of the intervals between charges that has to match the cadence.
+const subscriptionMinRegularity = 0.75
+
+// subscription is a payee that charges the same amount on a regular schedule.
+type subscription struct {
+	Payee        string
+	Amount       float64
+	Cadence      *subscriptionCadence
+	Expenditures []*models.Expenditure
+	NextCharge   time.Time
+	Active       bool
+}
+
+// AnnualCost returns what the subscription costs in a year.
+func (s *subscription) AnnualCost() float64 {
+	return s.Amount * s.Cadence.PerYear
+}
+
+// Last returns the most recent charge.
+func (s *subscription) Last() *models.Expenditure {
+	return s.Expenditures[len(s.Expenditures)-1]
+}
+
+// subscriptionPayee returns the name a charge is grouped by, the payee or the description when there is none.
+func subscriptionPayee(e *models.Expenditure) string {
+	if payee := strings.TrimSpace(e.Payee); payee != "" {
+		return payee
+	}
+
+	return strings.TrimSpace(e.Description)
+}
+
+// median returns the median of values, which are sorted in place.
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	middle := len(values) / 2
+	if len(values)%2 == 1 {
+		return values[middle]
+	}
+
+	return (values[middle-1] + values[middle]) / 2
+}
+
+// detectCadence returns the cadence the charges, sorted by date, follow or nil if they are not regular.
+func detectCadence(charges []*models.Expenditure) *subscriptionCadence {
+	if len(charges) < 2 {
+		return nil
+	}
+
+	intervals := []float64{}
+	for i := 1; i < len(charges); i++ {
+		intervals = append(intervals, charges[i].Date.Sub(charges[i-1].Date).Hours()/24)
+	}
+
+	typical := median(append([]float64{}, intervals...))
+	for _, cadence := range subscriptionCadences {
+		if len(charges) < cadence.MinCharges || math.Abs(typical-cadence.Days) > cadence.Tolerance {
+			continue
+		}
+
+		regular := 0
+		for _, interval := range intervals {
+			if math.Abs(interval-cadence.Days) <= cadence.Tolerance {
+				regular++
+			}
+		}
+		if float64(regular)/float64(len(intervals)) >= subscriptionMinRegularity {
+			return cadence
+		}
+	}
+
+	return nil
+}
+
+// detectSubscriptions looks for expenses of the same amount to the same payee
+// at regular intervals. Expenditures created from recurring templates are
+// already known and skipped. now decides whether a subscription is still active.
+func detectSubscriptions(now time.Time) ([]*subscription, error) {
+	expenditures := []*models.Expenditure{}
+	q := db.DB.Preload("Category").Where("direction = ? AND recurring_id = 0", models.DirectionExpense).Order("date asc")
+	if q = q.Find(&expenditures); q.Error != nil {
+		return nil, q.Error
+	}
+
+	type group struct {
+		payee  string
+		amount float64
+	}
+	keys := []group{}
+	groups := map[group][]*models.Expenditure{}
+	for _, e := range expenditures {
+		payee := subscriptionPayee(e)
+		if payee == "" {
+			continue
+		}
+
+		key := group{strings.ToLower(payee), math.Floor(e.Amount*100+0.5) / 100}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], e)
+	}
+
+	subscriptions := []*subscription{}
+	for _, key := range keys {
+		charges := groups[key]
+		cadence := detectCadence(charges)
+		if cadence == nil {
+			continue
+		}
+
+		last := charges[len(charges)-1]
+		schedule := &models.Recurring{Unit: cadence.Unit, Interval: cadence.Interval, StartDate: last.Date}
+		next := schedule.Occurrence(1)
+
+		subscriptions = append(subscriptions, &subscription{
+			Payee:        subscriptionPayee(last),
+			Amount:       key.amount,
+			Cadence:      cadence,
+			Expenditures: charges,
+			NextCharge:   next,
+			Active:       !now.After(next.Add(time.Duration(cadence.Tolerance*24) * time.Hour)),
+		})
+	}
+
+	sort.SliceStable(subscriptions, func(i, j int) bool {
+		return subscriptions[i].AnnualCost() > subscriptions[j].AnnualCost()
+	})
+
+	return subscriptions, nil
+}
+
+// findSubscription returns the detected subscription with the given payee and amount.
+func findSubscription(now time.Time, payee string, amount float64) (*subscription, error) {
+	subscriptions, err := detectSubscriptions(now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range subscriptions {
+		if strings.EqualFold(s.Payee, strings.TrimSpace(payee)) && math.Abs(s.Amount-amount) < 0.005 {
+			return s, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// convertSubscription creates a recurring template for s that starts at the
+// first expected charge after now, so no past or late charges are created
+// that the bank import would bring in as well. The charges that were found are
+// linked to the template so they are no longer reported as a subscription.
+func convertSubscription(s *subscription, now time.Time) (*models.Recurring, error) {
+	if !s.Active {
+		return nil, errSubscriptionInactive
+	}
+
+	last := s.Last()
+	schedule := &models.Recurring{Unit: s.Cadence.Unit, Interval: s.Cadence.Interval, StartDate: last.Date}
+	start := schedule.Occurrence(1)
+	for n := 2; !start.After(now); n++ {
+		start = schedule.Occurrence(n)
+	}
+
+	recurring := &models.Recurring{
+		Name:        s.Payee,
+		Amount:      s.Amount,
+		Direction:   models.DirectionExpense,
+		Payee:       last.Payee,
+		Description: last.Description,
+		Category:    last.Category,
+		CategoryID:  last.CategoryID,
+		AccountID:   last.AccountID,
+		Unit:        s.Cadence.Unit,
+		Interval:    s.Cadence.Interval,
+		StartDate:   start,
+		NextDate:    start,
+	}
+
+	ids := []uint{}
+	for _, e := range s.Expenditures {
+		ids = append(ids, e.ID)
+	}
+
+	tx := db.DB.Begin()
+	if q := tx.Create(recurring); q.Error != nil {
+		tx.Rollback()
+		return nil, q.Error
+	}
+	if q := tx.Model(&models.Expenditure{}).Where("id IN (?)", ids).UpdateColumn("recurring_id", recurring.ID); q.Error != nil {
+		tx.Rollback()
+		return nil, q.Error
+	}
+
+	return recurring, tx.Commit().Error
+}
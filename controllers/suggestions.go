@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/trtstm/budgetr/models"
+)
+
+// categorySuggestion is a category with the probability that an expenditure belongs to it.
+type categorySuggestion struct {
+	Category    *models.Category
+	Probability float64
+}
+
+type categoryClass struct {
+	category  *models.Category
+	documents int
+	features  map[string]int
+	total     int
+}
+
+// categoryClassifier is a naive Bayes classifier that learns categories
+// from the description, amount and weekday of categorized expenditures.
+type categoryClassifier struct {
+	classes    map[uint]*categoryClass
+	vocabulary map[string]bool
+	documents  int
+}
+
+// expenditureFeatures returns the features the classifier uses for e.
+func expenditureFeatures(e *models.Expenditure) []string {
+	features := []string{}
+	for _, token := range strings.FieldsFunc(strings.ToLower(e.Description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		// Card numbers, dates and the like say nothing about the category.
+		if len(token) < 2 || strings.IndexFunc(token, unicode.IsLetter) < 0 {
+			continue
+		}
+		features = append(features, "word:"+token)
+	}
+
+	if payee := strings.ToLower(strings.TrimSpace(e.Payee)); payee != "" {
+		features = append(features, "payee:"+payee)
+	}
+
+	if e.Amount > 0 {
+		// Amounts are bucketed on a logarithmic scale: 1-2, 2-4, 4-8, ...
+		features = append(features, fmt.Sprintf("amount:%d", int(math.Log2(e.Amount+1))))
+	}
+
+	if !e.Date.IsZero() {
+		features = append(features, "weekday:"+e.Date.Weekday().String())
+	}
+
+	return features
+}
+
+// trainCategoryClassifier learns from expenditures, the ones without a category are ignored.
+func trainCategoryClassifier(expenditures []*models.Expenditure) *categoryClassifier {
+	c := &categoryClassifier{
+		classes:    map[uint]*categoryClass{},
+		vocabulary: map[string]bool{},
+	}
+
+	for _, expenditure := range expenditures {
+		if expenditure.Category == nil {
+			continue
+		}
+
+		class, ok := c.classes[expenditure.Category.ID]
+		if !ok {
+			class = &categoryClass{category: expenditure.Category, features: map[string]int{}}
+			c.classes[expenditure.Category.ID] = class
+		}
+
+		class.documents++
+		c.documents++
+		for _, feature := range expenditureFeatures(expenditure) {
+			class.features[feature]++
+			class.total++
+			c.vocabulary[feature] = true
+		}
+	}
+
+	return c
+}
+
+// suggest returns the categories for e, most likely first.
+func (c *categoryClassifier) suggest(e *models.Expenditure) []*categorySuggestion {
+	features := expenditureFeatures(e)
+
+	suggestions := []*categorySuggestion{}
+	scores := []float64{}
+	best := math.Inf(-1)
+	for _, class := range c.classes {
+		score := math.Log(float64(class.documents) / float64(c.documents))
+		for _, feature := range features {
+			// Laplace smoothing so unseen features don't rule out a category.
+			score += math.Log(float64(class.features[feature]+1) / float64(class.total+len(c.vocabulary)))
+		}
+
+		suggestions = append(suggestions, &categorySuggestion{Category: class.category})
+		scores = append(scores, score)
+		best = math.Max(best, score)
+	}
+
+	sum := 0.0
+	for i, score := range scores {
+		suggestions[i].Probability = math.Exp(score - best)
+		sum += suggestions[i].Probability
+	}
+	for _, suggestion := range suggestions {
+		suggestion.Probability /= sum
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Probability != suggestions[j].Probability {
+			return suggestions[i].Probability > suggestions[j].Probability
+		}
+		return suggestions[i].Category.Name < suggestions[j].Category.Name
+	})
+
+	return suggestions
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+// normalizeTagName trims and lowercases a tag so "Kids" and "kids " are the same tag.
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// findOrCreateTags returns the tags with the given names, creating the missing ones.
+// Empty and repeated names are ignored.
+func findOrCreateTags(names []string) ([]*models.Tag, error) {
+	tags := []*models.Tag{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = normalizeTagName(name)
+		if len(name) == 0 || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		tag := &models.Tag{Name: name}
+		if q := db.DB.FirstOrCreate(tag, "name = ?", tag.Name); q.Error != nil {
+			return nil, q.Error
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+type tagController struct {
+}
+
+func (c *tagController) Index(ctx echo.Context) error {
+	tags := []*models.Tag{}
+
+	if q := db.DB.Order("name asc").Find(&tags); q.Error != nil {
+		log.Errorf("TagController::Index Could not execute find query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("TagController::Index Returning %d tags.", len(tags))
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data": TransformTag(tags...),
+	})
+}
+
+func (c *tagController) Create(ctx echo.Context) error {
+	params := &struct {
+		Name string `json:"name" form:"name"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("TagController::Create Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	tag := &models.Tag{Name: normalizeTagName(params.Name)}
+	if len(tag.Name) == 0 {
+		log.Infof("TagController::Create Name cant be empty.")
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	count := 0
+	if q := db.DB.Model(&models.Tag{}).Where("name = ?", tag.Name).Count(&count); q.Error != nil {
+		log.Errorf("TagController::Create Count failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	if count > 0 {
+		log.Infof("TagController::Create Tag '%s' already exists.", tag.Name)
+		return ctx.NoContent(http.StatusConflict)
+	}
+
+	if q := db.DB.Create(tag); q.Error != nil {
+		log.Errorf("TagController::Create Create failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("TagController::Create Tag created: %+v.", tag)
+	return ctx.JSON(http.StatusCreated, TransformTag(tag)[0])
+}
+
+func (c *tagController) Update(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("TagController::Update Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	params := &struct {
+		Name string `json:"name" form:"name"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("TagController::Update Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	tag := &models.Tag{}
+	if q := db.DB.Where("id = ?", id).First(tag); q.Error != nil {
+		log.Infof("TagController::Update Could not find tag '%d': '%v'.", id, q.Error)
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	name := normalizeTagName(params.Name)
+	if len(name) == 0 {
+		log.Infof("TagController::Update Name cant be empty.")
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	count := 0
+	if q := db.DB.Model(&models.Tag{}).Where("name = ? AND id <> ?", name, tag.ID).Count(&count); q.Error != nil {
+		log.Errorf("TagController::Update Count failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	if count > 0 {
+		log.Infof("TagController::Update Tag '%s' already exists.", name)
+		return ctx.NoContent(http.StatusConflict)
+	}
+
+	oldName := tag.Name
+	tag.Name = name
+	if q := db.DB.Save(tag); q.Error != nil {
+		log.Errorf("TagController::Update Could not save tag: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("TagController::Update Changed name from '%s' to '%s'.", oldName, tag.Name)
+	return ctx.JSON(http.StatusOK, TransformTag(tag)[0])
+}
+
+// Delete removes a tag from all expenditures and deletes it.
+func (c *tagController) Delete(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("TagController::Delete Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	tx := db.DB.Begin()
+	if q := tx.Exec("DELETE FROM expenditure_tags WHERE tag_id = ?", id); q.Error != nil {
+		tx.Rollback()
+		log.Errorf("TagController::Delete Could not remove tag from expenditures: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	// Hard delete so the name can be used again.
+	q := tx.Unscoped().Where("id = ?", id).Delete(&models.Tag{})
+	if q.Error != nil {
+		tx.Rollback()
+		log.Errorf("TagController::Delete Delete failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	if q.RowsAffected == 0 {
+		tx.Rollback()
+		log.Infof("TagController::Delete Could not delete tag `%d`. Does not exist.", id)
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	if q := tx.Commit(); q.Error != nil {
+		log.Errorf("TagController::Delete Commit failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("TagController::Delete Tag '%d' deleted.", id)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// TagController for /tags endpoint.
+var TagController tagController
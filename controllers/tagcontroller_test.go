@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTags(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		date := time.Date(2017, 7, 10, 12, 0, 0, 0, time.Local)
+
+		create := func(amount float64, tags ...string) *models.Expenditure {
+			expenditure, _, err := createExpenditure(models.DirectionExpense, &expenditureParams{Date: date, Amount: amount, Tags: tags})
+			So(err, ShouldBeNil)
+			return expenditure
+		}
+
+		index := func(url string) []*ExpenditureResponse {
+			r := httptest.NewRequest("GET", url, nil)
+			w := httptest.NewRecorder()
+			So(ExpenditureController.Index(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			answer := &expenditureListResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(answer), ShouldBeNil)
+			return answer.Data
+		}
+
+		var hotel *models.Expenditure
+		Convey("Creating expenditures with tags.", t, func() {
+			hotel = create(300, "Holiday-2017", " kids", "holiday-2017")
+			So(len(hotel.Tags), ShouldEqual, 2)
+			So(hotel.Tags[0].Name, ShouldEqual, "holiday-2017")
+
+			create(40, "holiday-2017")
+			create(25, "kids")
+		})
+
+		Convey("Filtering expenditures by tag.", t, func() {
+			So(len(index("/api/expenditures?tag=holiday-2017")), ShouldEqual, 2)
+
+			data := index("/api/expenditures?tag=holiday-2017&tag=kids")
+			So(len(data), ShouldEqual, 1)
+			So(data[0].ID, ShouldEqual, hotel.ID)
+			So(len(data[0].Tags), ShouldEqual, 2)
+		})
+
+		Convey("Getting per tag totals.", t, func() {
+			r := httptest.NewRequest("GET", "/api/stats/tags", nil)
+			w := httptest.NewRecorder()
+			So(TagStatsController.Index(e.NewContext(r, w)), ShouldBeNil)
+
+			stats := []*TagStatsResponse{}
+			So(json.NewDecoder(w.Result().Body).Decode(&stats), ShouldBeNil)
+			So(len(stats), ShouldEqual, 2)
+			So(stats[0].Name, ShouldEqual, "holiday-2017")
+			So(stats[0].Total, ShouldEqual, 340)
+			So(stats[1].Name, ShouldEqual, "kids")
+			So(stats[1].Count, ShouldEqual, 2)
+		})
+
+		Convey("Replacing the tags of an expenditure.", t, func() {
+			r := httptest.NewRequest("POST", "/api/expenditures/:id", strings.NewReader(`{"tags": ["reimbursable"]}`))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			c := e.NewContext(r, w)
+			c.SetParamNames("id")
+			c.SetParamValues(strconv.Itoa(int(hotel.ID)))
+			So(ExpenditureController.Update(c), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			So(len(index("/api/expenditures?tag=kids")), ShouldEqual, 1)
+			So(len(index("/api/expenditures?tag=reimbursable")), ShouldEqual, 1)
+		})
+
+		Convey("Deleting a tag.", t, func() {
+			tag := &models.Tag{}
+			db.DB.Where("name = ?", "kids").First(tag)
+
+			r := httptest.NewRequest("DELETE", "/api/tags/:id", nil)
+			w := httptest.NewRecorder()
+			c := e.NewContext(r, w)
+			c.SetParamNames("id")
+			c.SetParamValues(strconv.Itoa(int(tag.ID)))
+			So(TagController.Delete(c), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			So(len(index("/api/expenditures?tag=kids")), ShouldEqual, 0)
+
+			r = httptest.NewRequest("POST", "/api/tags", strings.NewReader(`{"name": "Kids"}`))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w = httptest.NewRecorder()
+			So(TagController.Create(e.NewContext(r, w)), ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusCreated)
+		})
+	})
+}
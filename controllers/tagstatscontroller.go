@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/log"
+)
+
+// TagStatsResponse contains statistics for a tag.
+type TagStatsResponse struct {
+	ID    uint    `json:"id"`
+	Name  string  `json:"name"`
+	Count int     `json:"count"`
+	Total float64 `json:"total"`
+}
+
+type tagStatsController struct {
+}
+
+// Index returns the totals per tag. An expenditure with several tags counts for each of them.
+func (c *tagStatsController) Index(ctx echo.Context) error {
+	stats := []*TagStatsResponse{}
+
+	start, end, err := parseDateRange(ctx)
+	if err != nil {
+		log.Infof("TagStatsController::Index %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	direction, err := parseDirectionParam(ctx)
+	if err != nil {
+		log.Infof("TagStatsController::Index %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	q := db.DB.Table("expenditure_tags")
+	q = q.Joins("JOIN expenditures ON expenditures.id = expenditure_tags.expenditure_id")
+	q = q.Joins("JOIN tags ON tags.id = expenditure_tags.tag_id")
+	q = q.Where("expenditures.deleted_at IS NULL AND tags.deleted_at IS NULL")
+	q = q.Where("expenditures.direction = ?", direction)
+	q = q.Group("tags.id").Order("tags.name")
+	q = q.Select("tags.id AS id, tags.name AS name, COUNT(expenditures.id) AS count, SUM(expenditures.amount) AS total")
+	if !start.IsZero() {
+		q = dateRangeQuery(start, end, q)
+	}
+
+	if q = q.Scan(&stats); q.Error != nil {
+		log.Errorf("TagStatsController::Index Could not execute query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.WithFields(log.Fields{"results": len(stats)}).Infof("TagStatsController::Index Returning tag statistics.")
+	return ctx.JSON(http.StatusOK, stats)
+}
+
+// TagStatsController for /stats/tags endpoint.
+var TagStatsController tagStatsController
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+// maxTimeSeriesBuckets limits the length of a time series.
+const maxTimeSeriesBuckets = 3660
+
+// TimeSeriesCategoryResponse is the total of a category in one bucket.
+type TimeSeriesCategoryResponse struct {
+	ID    uint              `json:"id"`
+	Name  models.NullString `json:"name"`
+	Total float64           `json:"total"`
+}
+
+// TimeSeriesBucketResponse is the total of the interval [Start, End).
+type TimeSeriesBucketResponse struct {
+	Start      time.Time                     `json:"start"`
+	End        time.Time                     `json:"end"`
+	Total      float64                       `json:"total"`
+	Categories []*TimeSeriesCategoryResponse `json:"categories,omitempty"`
+}
+
+// truncateInterval returns the start of the day, week, month or year t is in.
+// Weeks start on monday.
+func truncateInterval(t time.Time, interval string) (time.Time, error) {
+	year, month, day := t.Date()
+	switch interval {
+	case "day":
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location()), nil
+	case "week":
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location()), nil
+	case "month":
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location()), nil
+	case "year":
+		return time.Date(year, 1, 1, 0, 0, 0, 0, t.Location()), nil
+	}
+
+	return t, fmt.Errorf("unknown interval `%s`", interval)
+}
+
+// nextInterval returns the start of the interval after the one starting at t.
+func nextInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case "week":
+		return t.AddDate(0, 0, 7)
+	case "month":
+		return t.AddDate(0, 1, 0)
+	case "year":
+		return t.AddDate(1, 0, 0)
+	}
+
+	return t.AddDate(0, 0, 1)
+}
+
+type timeSeriesStatsController struct {
+}
+
+func (c *timeSeriesStatsController) Index(ctx echo.Context) error {
+	interval := ctx.QueryParam("interval")
+	if len(interval) == 0 {
+		interval = "month"
+	}
+
+	start, end, err := parseDateRange(ctx)
+	if err != nil {
+		log.Infof("TimeSeriesStatsController::Index %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+	if start.IsZero() || !start.Before(end) {
+		log.Infof("TimeSeriesStatsController::Index Invalid range [%s, %s).", start, end)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	direction, err := parseDirectionParam(ctx)
+	if err != nil {
+		log.Infof("TimeSeriesStatsController::Index %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	first, err := truncateInterval(start, interval)
+	if err != nil {
+		log.Infof("TimeSeriesStatsController::Index %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	buckets := []*TimeSeriesBucketResponse{}
+	for bucket := first; bucket.Before(end); bucket = nextInterval(bucket, interval) {
+		if len(buckets) == maxTimeSeriesBuckets {
+			log.Infof("TimeSeriesStatsController::Index Too many %ss in [%s, %s).", interval, start, end)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+
+		buckets = append(buckets, &TimeSeriesBucketResponse{Start: bucket, End: nextInterval(bucket, interval)})
+	}
+
+	rows := []*struct {
+		Date   time.Time
+		ID     uint
+		Name   models.NullString
+		Amount float64
+	}{}
+	q := categoryAmountsQuery(direction)
+	q = q.Select("expenditures.date AS date, COALESCE(categories.id, 0) AS id, categories.name AS name, COALESCE(expenditure_splits.amount, expenditures.amount) AS amount")
+	if q = dateRangeQuery(start, end, q).Scan(&rows); q.Error != nil {
+		log.Errorf("TimeSeriesStatsController::Index Could not execute query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	perCategory := ctx.QueryParam("categories") == "true"
+	categories := []*TimeSeriesCategoryResponse{}
+	index := map[uint]int{}
+	totals := make([]map[uint]float64, len(buckets))
+	for _, row := range rows {
+		date := row.Date.In(start.Location())
+		i := sort.Search(len(buckets), func(i int) bool {
+			return date.Before(buckets[i].End)
+		})
+		if i == len(buckets) {
+			continue
+		}
+
+		buckets[i].Total += row.Amount
+		if !perCategory {
+			continue
+		}
+
+		if _, ok := index[row.ID]; !ok {
+			index[row.ID] = len(categories)
+			categories = append(categories, &TimeSeriesCategoryResponse{ID: row.ID, Name: row.Name})
+		}
+		if totals[i] == nil {
+			totals[i] = map[uint]float64{}
+		}
+		totals[i][row.ID] += row.Amount
+	}
+
+	if perCategory {
+		// Uncategorized comes last, the others by name.
+		sort.Slice(categories, func(i, j int) bool {
+			if categories[i].Name.Valid != categories[j].Name.Valid {
+				return categories[i].Name.Valid
+			}
+			return categories[i].Name.String < categories[j].Name.String
+		})
+
+		// Every bucket lists every category so the series line up.
+		for i, bucket := range buckets {
+			bucket.Categories = []*TimeSeriesCategoryResponse{}
+			for _, category := range categories {
+				bucket.Categories = append(bucket.Categories, &TimeSeriesCategoryResponse{
+					ID:    category.ID,
+					Name:  category.Name,
+					Total: totals[i][category.ID],
+				})
+			}
+		}
+	}
+
+	log.WithFields(log.Fields{"interval": interval, "start": start, "end": end, "results": len(buckets)}).Infof("Returning time series statistics.")
+	return ctx.JSON(http.StatusOK, buckets)
+}
+
+// TimeSeriesStatsController for /stats/timeseries endpoint.
+var TimeSeriesStatsController timeSeriesStatsController
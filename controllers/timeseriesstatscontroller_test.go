@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTimeSeriesStatsControllerIndex(t *testing.T) {
+	e := echo.New()
+
+	withDb(func() {
+		create := func(date time.Time, amount float64, category string) {
+			_, _, err := createExpenditure(models.DirectionExpense, &expenditureParams{Date: date, Amount: amount, Category: category})
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		create(time.Date(2017, 1, 3, 12, 0, 0, 0, time.UTC), 10, "food")
+		create(time.Date(2017, 1, 20, 12, 0, 0, 0, time.UTC), 20, "food")
+		create(time.Date(2017, 3, 2, 12, 0, 0, 0, time.UTC), 700, "rent")
+		create(time.Date(2017, 3, 9, 12, 0, 0, 0, time.UTC), 5, "")
+
+		index := func(url string) ([]*TimeSeriesBucketResponse, int) {
+			r := httptest.NewRequest("GET", url, nil)
+			w := httptest.NewRecorder()
+			So(TimeSeriesStatsController.Index(e.NewContext(r, w)), ShouldBeNil)
+
+			buckets := []*TimeSeriesBucketResponse{}
+			if w.Code == http.StatusOK {
+				So(json.NewDecoder(w.Result().Body).Decode(&buckets), ShouldBeNil)
+			}
+			return buckets, w.Code
+		}
+
+		Convey("Getting monthly totals.", t, func() {
+			buckets, code := index("/api/stats/timeseries?interval=month&start=2017-01-01T00:00:00Z&end=2017-04-01T00:00:00Z")
+			So(code, ShouldEqual, http.StatusOK)
+			So(len(buckets), ShouldEqual, 3)
+			So(buckets[0].Total, ShouldEqual, 30)
+			So(buckets[1].Total, ShouldEqual, 0)
+			So(buckets[1].Start.Equal(time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)), ShouldBeTrue)
+			So(buckets[2].Total, ShouldEqual, 705)
+			So(buckets[0].Categories, ShouldBeNil)
+		})
+
+		Convey("Getting weekly totals per category.", t, func() {
+			buckets, code := index("/api/stats/timeseries?interval=week&categories=true&start=2017-02-27T00:00:00Z&end=2017-03-13T00:00:00Z")
+			So(code, ShouldEqual, http.StatusOK)
+			So(len(buckets), ShouldEqual, 2)
+			So(len(buckets[0].Categories), ShouldEqual, 2)
+			So(buckets[0].Categories[0].Name.String, ShouldEqual, "rent")
+			So(buckets[0].Categories[0].Total, ShouldEqual, 700)
+			So(buckets[0].Categories[1].Name.Valid, ShouldBeFalse)
+			So(buckets[0].Categories[1].Total, ShouldEqual, 0)
+			So(buckets[1].Categories[1].Total, ShouldEqual, 5)
+		})
+
+		Convey("Invalid parameters are rejected.", t, func() {
+			for _, url := range []string{
+				"/api/stats/timeseries?interval=month",
+				"/api/stats/timeseries?interval=hour&start=2017-01-01T00:00:00Z&end=2017-04-01T00:00:00Z",
+				"/api/stats/timeseries?interval=day&start=2000-01-01T00:00:00Z&end=2017-04-01T00:00:00Z",
+			} {
+				_, code := index(url)
+				So(code, ShouldEqual, http.StatusBadRequest)
+			}
+		})
+	})
+}
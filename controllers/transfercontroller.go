@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/trtstm/budgetr/db"
+	"github.com/trtstm/budgetr/log"
+	"github.com/trtstm/budgetr/models"
+)
+
+type transferController struct {
+}
+
+func (c *transferController) Index(ctx echo.Context) error {
+	transfers := []*models.Transfer{}
+
+	start, end, err := parseDateRange(ctx)
+	if err != nil {
+		log.Infof("TransferController::Index %v", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	q := db.DB.Preload("FromAccount").Preload("ToAccount")
+	if !start.IsZero() {
+		q = dateRangeQuery(start, end, q)
+	}
+
+	if accountQ := ctx.QueryParam("account"); len(accountQ) > 0 {
+		accountID, err := strconv.ParseUint(accountQ, 10, 64)
+		if err != nil {
+			log.Infof("TransferController::Index Could not parse account `%s`: '%v'.", accountQ, err)
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		q = q.Where("from_account_id = ? OR to_account_id = ?", accountID, accountID)
+	}
+
+	if q = q.Order("date desc").Find(&transfers); q.Error != nil {
+		log.Errorf("TransferController::Index Could not execute find query: %v", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("TransferController::Index Returning %d transfers.", len(transfers))
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"data": TransformTransfer(transfers...),
+	})
+}
+
+func (c *transferController) Create(ctx echo.Context) error {
+	params := &struct {
+		Date   time.Time `json:"date" form:"date"`
+		Amount float64   `json:"amount" form:"amount"`
+		From   uint      `json:"from" form:"from"`
+		To     uint      `json:"to" form:"to"`
+	}{}
+
+	if err := ctx.Bind(params); err != nil {
+		log.Infof("TransferController::Create Could not bind params: '%v'.", err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if params.Amount <= 0 {
+		log.Infof("TransferController::Create Amount should be positive.")
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	if params.From == 0 || params.To == 0 || params.From == params.To {
+		log.Infof("TransferController::Create Two different accounts should be given.")
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	from, err := findAccount(params.From)
+	if err != nil {
+		log.Infof("TransferController::Create Could not find account '%d': '%v'.", params.From, err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	to, err := findAccount(params.To)
+	if err != nil {
+		log.Infof("TransferController::Create Could not find account '%d': '%v'.", params.To, err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	transfer := &models.Transfer{
+		Amount:      params.Amount,
+		Date:        params.Date,
+		FromAccount: from,
+		ToAccount:   to,
+	}
+
+	if q := db.DB.Create(transfer); q.Error != nil {
+		log.Errorf("TransferController::Create Create failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	log.Infof("TransferController::Create Transfer created: %+v.", transfer)
+	return ctx.JSON(http.StatusCreated, TransformTransfer(transfer)[0])
+}
+
+func (c *transferController) Delete(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Infof("TransferController::Delete Could not parse id `%s`: '%v'.", ctx.Param("id"), err)
+		return ctx.NoContent(http.StatusBadRequest)
+	}
+
+	q := db.DB.Where("id = ?", id).Delete(&models.Transfer{})
+	if q.Error != nil {
+		log.Errorf("TransferController::Delete Delete failed: '%v'.", q.Error)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	if q.RowsAffected == 0 {
+		log.Infof("TransferController::Delete Could not delete transfer `%d`. Does not exist.", id)
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	log.Infof("TransferController::Delete Transfer '%d' deleted.", id)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// TransferController for /transfers endpoint.
+var TransferController transferController
@@ -8,8 +8,15 @@ import (
 
 // CategoryResponse holds the response data for a category.
 type CategoryResponse struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Parent    *uint  `json:"parent"`
+	Color     string `json:"color"`
+	Icon      string `json:"icon"`
+	SortOrder int    `json:"sort_order"`
+	Archived  bool   `json:"archived"`
+	// Children is only filled in when the category tree is requested.
+	Children []*CategoryResponse `json:"children,omitempty"`
 }
 
 // TransformCategory transforms one or more categories.
@@ -17,9 +24,19 @@ func TransformCategory(categories ...*models.Category) (result []*CategoryRespon
 	result = []*CategoryResponse{}
 	for _, category := range categories {
 		resp := &CategoryResponse{
-			ID:   category.ID,
-			Name: category.Name,
+			ID:        category.ID,
+			Name:      category.Name,
+			Color:     category.Color,
+			Icon:      category.Icon,
+			SortOrder: category.SortOrder,
+			Archived:  category.Archived,
 		}
+
+		if category.ParentID != 0 {
+			parent := category.ParentID
+			resp.Parent = &parent
+		}
+
 		result = append(result, resp)
 	}
 
@@ -28,10 +45,17 @@ func TransformCategory(categories ...*models.Category) (result []*CategoryRespon
 
 // ExpenditureResponse holds the response data for an expenditure.
 type ExpenditureResponse struct {
-	ID       uint              `json:"id"`
-	Amount   float64           `json:"amount"`
-	Date     time.Time         `json:"date"`
-	Category *CategoryResponse `json:"category"`
+	ID          uint              `json:"id"`
+	Amount      float64           `json:"amount"`
+	Date        time.Time         `json:"date"`
+	Direction   models.Direction  `json:"direction"`
+	Payee       string            `json:"payee"`
+	Description string            `json:"description"`
+	Notes       string            `json:"notes"`
+	Category    *CategoryResponse `json:"category"`
+	Account     *AccountResponse  `json:"account"`
+	Tags        []*TagResponse    `json:"tags"`
+	Splits      []*SplitResponse  `json:"splits"`
 }
 
 // TransformExpenditure transforms one or more expenditures.
@@ -39,15 +63,394 @@ func TransformExpenditure(expenditures ...*models.Expenditure) (result []*Expend
 	result = []*ExpenditureResponse{}
 	for _, expenditure := range expenditures {
 		resp := &ExpenditureResponse{
-			ID:     expenditure.ID,
-			Amount: expenditure.Amount,
-			Date:   expenditure.Date,
+			ID:          expenditure.ID,
+			Amount:      expenditure.Amount,
+			Date:        expenditure.Date,
+			Direction:   expenditure.Direction,
+			Payee:       expenditure.Payee,
+			Description: expenditure.Description,
+			Notes:       expenditure.Notes,
+		}
+
+		if resp.Direction == "" {
+			resp.Direction = models.DirectionExpense
 		}
 
 		if expenditure.Category != nil {
 			resp.Category = TransformCategory(expenditure.Category)[0]
 		}
 
+		if expenditure.Account != nil {
+			resp.Account = TransformAccount(expenditure.Account)[0]
+		}
+
+		resp.Tags = TransformTag(expenditure.Tags...)
+		resp.Splits = TransformSplit(expenditure.Splits...)
+
+		result = append(result, resp)
+	}
+
+	return
+}
+
+// BudgetResponse holds the response data for a budget.
+type BudgetResponse struct {
+	ID       uint              `json:"id"`
+	Period   string            `json:"period"`
+	Limit    float64           `json:"limit"`
+	Category *CategoryResponse `json:"category"`
+}
+
+// TransformBudget transforms one or more budgets.
+func TransformBudget(budgets ...*models.Budget) (result []*BudgetResponse) {
+	result = []*BudgetResponse{}
+	for _, budget := range budgets {
+		resp := &BudgetResponse{
+			ID:     budget.ID,
+			Period: budget.Period,
+			Limit:  budget.Amount,
+		}
+
+		if budget.Category != nil {
+			resp.Category = TransformCategory(budget.Category)[0]
+		}
+
+		result = append(result, resp)
+	}
+
+	return
+}
+
+// AccountResponse holds the response data for an account.
+type AccountResponse struct {
+	ID             uint               `json:"id"`
+	Name           string             `json:"name"`
+	Type           models.AccountType `json:"type"`
+	OpeningBalance float64            `json:"opening_balance"`
+}
+
+// TransformAccount transforms one or more accounts.
+func TransformAccount(accounts ...*models.Account) (result []*AccountResponse) {
+	result = []*AccountResponse{}
+	for _, account := range accounts {
+		resp := &AccountResponse{
+			ID:             account.ID,
+			Name:           account.Name,
+			Type:           account.Type,
+			OpeningBalance: account.OpeningBalance,
+		}
+		result = append(result, resp)
+	}
+
+	return
+}
+
+// TransferResponse holds the response data for a transfer.
+type TransferResponse struct {
+	ID     uint             `json:"id"`
+	Amount float64          `json:"amount"`
+	Date   time.Time        `json:"date"`
+	From   *AccountResponse `json:"from"`
+	To     *AccountResponse `json:"to"`
+}
+
+// TransformTransfer transforms one or more transfers.
+func TransformTransfer(transfers ...*models.Transfer) (result []*TransferResponse) {
+	result = []*TransferResponse{}
+	for _, transfer := range transfers {
+		resp := &TransferResponse{
+			ID:     transfer.ID,
+			Amount: transfer.Amount,
+			Date:   transfer.Date,
+		}
+
+		if transfer.FromAccount != nil {
+			resp.From = TransformAccount(transfer.FromAccount)[0]
+		}
+
+		if transfer.ToAccount != nil {
+			resp.To = TransformAccount(transfer.ToAccount)[0]
+		}
+
+		result = append(result, resp)
+	}
+
+	return
+}
+
+// ImportProfileResponse holds the response data for an import profile.
+type ImportProfileResponse struct {
+	ID                 uint   `json:"id"`
+	Name               string `json:"name"`
+	Delimiter          string `json:"delimiter"`
+	SkipRows           int    `json:"skip_rows"`
+	DateColumn         int    `json:"date_column"`
+	DateFormat         string `json:"date_format"`
+	AmountColumn       int    `json:"amount_column"`
+	DecimalSeparator   string `json:"decimal_separator"`
+	ThousandsSeparator string `json:"thousands_separator"`
+	DescriptionColumn  int    `json:"description_column"`
+	PayeeColumn        int    `json:"payee_column"`
+}
+
+// TransformImportProfile transforms one or more import profiles.
+func TransformImportProfile(profiles ...*models.ImportProfile) (result []*ImportProfileResponse) {
+	result = []*ImportProfileResponse{}
+	for _, profile := range profiles {
+		resp := &ImportProfileResponse{
+			ID:                 profile.ID,
+			Name:               profile.Name,
+			Delimiter:          profile.Delimiter,
+			SkipRows:           profile.SkipRows,
+			DateColumn:         profile.DateColumn,
+			DateFormat:         profile.DateFormat,
+			AmountColumn:       profile.AmountColumn,
+			DecimalSeparator:   profile.DecimalSeparator,
+			ThousandsSeparator: profile.ThousandsSeparator,
+			DescriptionColumn:  profile.DescriptionColumn,
+			PayeeColumn:        profile.PayeeColumn,
+		}
+		result = append(result, resp)
+	}
+
+	return
+}
+
+// DuplicateCandidateResponse holds the response data for a duplicate candidate.
+type DuplicateCandidateResponse struct {
+	ID          uint                 `json:"id"`
+	Score       float64              `json:"score"`
+	Reason      string               `json:"reason"`
+	Resolution  string               `json:"resolution"`
+	Expenditure *ExpenditureResponse `json:"expenditure"`
+	Duplicate   *ExpenditureResponse `json:"duplicate"`
+}
+
+// TransformDuplicateCandidate transforms one or more duplicate candidates.
+func TransformDuplicateCandidate(candidates ...*models.DuplicateCandidate) (result []*DuplicateCandidateResponse) {
+	result = []*DuplicateCandidateResponse{}
+	for _, candidate := range candidates {
+		resp := &DuplicateCandidateResponse{
+			ID:         candidate.ID,
+			Score:      candidate.Score,
+			Reason:     candidate.Reason,
+			Resolution: candidate.Resolution,
+		}
+
+		if candidate.Expenditure != nil {
+			resp.Expenditure = TransformExpenditure(candidate.Expenditure)[0]
+		}
+
+		if candidate.Duplicate != nil {
+			resp.Duplicate = TransformExpenditure(candidate.Duplicate)[0]
+		}
+
+		result = append(result, resp)
+	}
+
+	return
+}
+
+// CategoryRuleResponse holds the response data for a category rule.
+type CategoryRuleResponse struct {
+	ID        uint              `json:"id"`
+	Name      string            `json:"name"`
+	Priority  int               `json:"priority"`
+	Contains  string            `json:"contains"`
+	Pattern   string            `json:"pattern"`
+	MinAmount float64           `json:"min_amount"`
+	MaxAmount float64           `json:"max_amount"`
+	Direction models.Direction  `json:"direction"`
+	Account   uint              `json:"account"`
+	Weekdays  []int             `json:"weekdays"`
+	Category  *CategoryResponse `json:"category"`
+}
+
+// TransformCategoryRule transforms one or more category rules.
+func TransformCategoryRule(rules ...*models.CategoryRule) (result []*CategoryRuleResponse) {
+	result = []*CategoryRuleResponse{}
+	for _, rule := range rules {
+		resp := &CategoryRuleResponse{
+			ID:        rule.ID,
+			Name:      rule.Name,
+			Priority:  rule.Priority,
+			Contains:  rule.Contains,
+			Pattern:   rule.Pattern,
+			MinAmount: rule.MinAmount,
+			MaxAmount: rule.MaxAmount,
+			Direction: rule.Direction,
+			Account:   rule.AccountID,
+			Weekdays:  []int{},
+		}
+
+		for _, day := range rule.WeekdayList() {
+			resp.Weekdays = append(resp.Weekdays, int(day))
+		}
+
+		if rule.Category != nil {
+			resp.Category = TransformCategory(rule.Category)[0]
+		}
+
+		result = append(result, resp)
+	}
+
+	return
+}
+
+// CategorySuggestionResponse holds the response data for a category suggestion.
+type CategorySuggestionResponse struct {
+	Category    *CategoryResponse `json:"category"`
+	Probability float64           `json:"probability"`
+}
+
+// TransformCategorySuggestion transforms one or more category suggestions.
+func TransformCategorySuggestion(suggestions ...*categorySuggestion) (result []*CategorySuggestionResponse) {
+	result = []*CategorySuggestionResponse{}
+	for _, suggestion := range suggestions {
+		result = append(result, &CategorySuggestionResponse{
+			Category:    TransformCategory(suggestion.Category)[0],
+			Probability: suggestion.Probability,
+		})
+	}
+
+	return
+}
+
+// TagResponse holds the response data for a tag.
+type TagResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// TransformTag transforms one or more tags.
+func TransformTag(tags ...*models.Tag) (result []*TagResponse) {
+	result = []*TagResponse{}
+	for _, tag := range tags {
+		resp := &TagResponse{
+			ID:   tag.ID,
+			Name: tag.Name,
+		}
+		result = append(result, resp)
+	}
+
+	return
+}
+
+// SplitResponse holds the response data for a split of an expenditure.
+type SplitResponse struct {
+	Amount   float64           `json:"amount"`
+	Category *CategoryResponse `json:"category"`
+}
+
+// TransformSplit transforms one or more splits.
+func TransformSplit(splits ...*models.ExpenditureSplit) (result []*SplitResponse) {
+	result = []*SplitResponse{}
+	for _, split := range splits {
+		resp := &SplitResponse{
+			Amount: split.Amount,
+		}
+
+		if split.Category != nil {
+			resp.Category = TransformCategory(split.Category)[0]
+		}
+
+		result = append(result, resp)
+	}
+
+	return
+}
+
+// RecurringResponse holds the response data for a recurring template.
+type RecurringResponse struct {
+	ID          uint                  `json:"id"`
+	Name        string                `json:"name"`
+	Amount      float64               `json:"amount"`
+	Direction   models.Direction      `json:"direction"`
+	Payee       string                `json:"payee"`
+	Description string                `json:"description"`
+	Category    *CategoryResponse     `json:"category"`
+	Account     uint                  `json:"account"`
+	Unit        models.RecurrenceUnit `json:"unit"`
+	Interval    int                   `json:"interval"`
+	StartDate   time.Time             `json:"start_date"`
+	EndDate     *time.Time            `json:"end_date"`
+	NextDate    time.Time             `json:"next_date"`
+	Paused      bool                  `json:"paused"`
+}
+
+// TransformRecurring transforms one or more recurring templates.
+func TransformRecurring(recurrings ...*models.Recurring) (result []*RecurringResponse) {
+	result = []*RecurringResponse{}
+	for _, recurring := range recurrings {
+		resp := &RecurringResponse{
+			ID:          recurring.ID,
+			Name:        recurring.Name,
+			Amount:      recurring.Amount,
+			Direction:   recurring.Direction,
+			Payee:       recurring.Payee,
+			Description: recurring.Description,
+			Account:     recurring.AccountID,
+			Unit:        recurring.Unit,
+			Interval:    recurring.Interval,
+			StartDate:   recurring.StartDate,
+			NextDate:    recurring.NextDate,
+			Paused:      recurring.Paused,
+		}
+
+		if !recurring.EndDate.IsZero() {
+			endDate := recurring.EndDate
+			resp.EndDate = &endDate
+		}
+
+		if recurring.Category != nil {
+			resp.Category = TransformCategory(recurring.Category)[0]
+		}
+
+		result = append(result, resp)
+	}
+
+	return
+}
+
+// SubscriptionResponse holds the response data for a detected subscription.
+type SubscriptionResponse struct {
+	Payee       string                `json:"payee"`
+	Amount      float64               `json:"amount"`
+	Unit        models.RecurrenceUnit `json:"unit"`
+	Interval    int                   `json:"interval"`
+	AnnualCost  float64               `json:"annual_cost"`
+	Charges     int                   `json:"charges"`
+	FirstCharge time.Time             `json:"first_charge"`
+	LastCharge  time.Time             `json:"last_charge"`
+	NextCharge  time.Time             `json:"next_charge"`
+	Active      bool                  `json:"active"`
+	Category    *CategoryResponse     `json:"category"`
+	Account     uint                  `json:"account"`
+}
+
+// TransformSubscription transforms one or more detected subscriptions.
+func TransformSubscription(subscriptions ...*subscription) (result []*SubscriptionResponse) {
+	result = []*SubscriptionResponse{}
+	for _, s := range subscriptions {
+		last := s.Last()
+		resp := &SubscriptionResponse{
+			Payee:       s.Payee,
+			Amount:      s.Amount,
+			Unit:        s.Cadence.Unit,
+			Interval:    s.Cadence.Interval,
+			AnnualCost:  s.AnnualCost(),
+			Charges:     len(s.Expenditures),
+			FirstCharge: s.Expenditures[0].Date,
+			LastCharge:  last.Date,
+			NextCharge:  s.NextCharge,
+			Active:      s.Active,
+			Account:     last.AccountID,
+		}
+
+		if last.Category != nil {
+			resp.Category = TransformCategory(last.Category)[0]
+		}
+
 		result = append(result, resp)
 	}
 
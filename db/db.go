@@ -51,13 +51,70 @@ func SetupSchema() (err error) {
 	db := DB.AutoMigrate(
 		&models.Category{},
 		&models.Expenditure{},
+		&models.Budget{},
+		&models.Account{},
+		&models.Transfer{},
+		&models.ImportProfile{},
+		&models.DuplicateCandidate{},
+		&models.CategoryRule{},
+		&models.Tag{},
+		&models.ExpenditureSplit{},
+		&models.Recurring{},
 	)
 	if db.Error != nil {
 		log.Errorf("Failed to update database schema: %v", err)
 		return
 	}
 
+	if err = setupDefaultAccount(); err != nil {
+		log.Errorf("Failed to set up default account: %v", err)
+		return
+	}
+
+	if err = setupRecurringIDs(); err != nil {
+		log.Errorf("Failed to set up recurring ids: %v", err)
+		return
+	}
+
 	log.Info("Database schema updated.")
 
 	return nil
 }
+
+// setupDefaultAccount makes sure at least one account exists and
+// moves expenditures without an account to the default account.
+func setupDefaultAccount() error {
+	account := &models.Account{}
+	q := DB.Order("id asc").First(account)
+	if q.RecordNotFound() {
+		account = &models.Account{Name: models.DefaultAccountName, Type: models.AccountChecking}
+		q = DB.Create(account)
+	}
+	if q.Error != nil {
+		return q.Error
+	}
+
+	q = DB.Model(&models.Expenditure{}).Where("account_id IS NULL OR account_id = 0").Update("account_id", account.ID)
+	if q.Error != nil {
+		return q.Error
+	}
+	if q.RowsAffected > 0 {
+		log.Infof("Moved %d expenditures to account '%s'.", q.RowsAffected, account.Name)
+	}
+
+	return nil
+}
+
+// setupRecurringIDs marks expenditures from before recurring templates
+// existed as not created from a template.
+func setupRecurringIDs() error {
+	q := DB.Unscoped().Model(&models.Expenditure{}).Where("recurring_id IS NULL").UpdateColumn("recurring_id", 0)
+	if q.Error != nil {
+		return q.Error
+	}
+	if q.RowsAffected > 0 {
+		log.Infof("Marked %d expenditures as not recurring.", q.RowsAffected)
+	}
+
+	return nil
+}
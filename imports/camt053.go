@@ -0,0 +1,149 @@
+package imports
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) parse() (time.Time, error) {
+	if d.Date != "" {
+		return time.ParseInLocation("2006-01-02", strings.TrimSpace(d.Date), time.Local)
+	}
+
+	value := strings.TrimSpace(d.DateTime)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation("2006-01-02T15:04:05", value, time.Local)
+}
+
+// camtStatus is either <Sts>BOOK</Sts> or <Sts><Cd>BOOK</Cd></Sts> depending on the version.
+type camtStatus struct {
+	Value string `xml:",chardata"`
+	Code  string `xml:"Cd"`
+}
+
+type camtTransaction struct {
+	AccountServicerReference string   `xml:"Refs>AcctSvcrRef"`
+	EndToEndID               string   `xml:"Refs>EndToEndId"`
+	Creditor                 string   `xml:"RltdPties>Cdtr>Nm"`
+	CreditorParty            string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+	Debtor                   string   `xml:"RltdPties>Dbtr>Nm"`
+	DebtorParty              string   `xml:"RltdPties>Dbtr>Pty>Nm"`
+	Unstructured             []string `xml:"RmtInf>Ustrd"`
+	Structured               string   `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+}
+
+type camtEntry struct {
+	Amount                   string            `xml:"Amt"`
+	CreditDebit              string            `xml:"CdtDbtInd"`
+	Status                   camtStatus        `xml:"Sts"`
+	BookingDate              camtDate          `xml:"BookgDt"`
+	ValueDate                camtDate          `xml:"ValDt"`
+	EntryReference           string            `xml:"NtryRef"`
+	AccountServicerReference string            `xml:"AcctSvcrRef"`
+	AdditionalInformation    string            `xml:"AddtlNtryInf"`
+	Transactions             []camtTransaction `xml:"NtryDtls>TxDtls"`
+}
+
+type camtDocument struct {
+	Entries []camtEntry `xml:"BkToCstmrStmt>Stmt>Ntry"`
+}
+
+// ParseCAMT053 reads the booked entries from an ISO 20022 CAMT.053 statement.
+func ParseCAMT053(r io.Reader) ([]*Record, error) {
+	document := &camtDocument{}
+	if err := xml.NewDecoder(r).Decode(document); err != nil {
+		return nil, &ParseError{Err: err}
+	}
+
+	records := []*Record{}
+	for i, entry := range document.Entries {
+		status := strings.TrimSpace(entry.Status.Code)
+		if status == "" {
+			status = strings.TrimSpace(entry.Status.Value)
+		}
+		if status != "" && status != "BOOK" {
+			continue
+		}
+
+		amount, err := strconv.ParseFloat(strings.TrimSpace(entry.Amount), 64)
+		if err != nil {
+			return nil, &ParseError{Err: fmt.Errorf("entry %d: invalid amount `%s`", i+1, entry.Amount)}
+		}
+		if strings.TrimSpace(entry.CreditDebit) == "DBIT" {
+			amount = -amount
+		}
+
+		date, err := entry.BookingDate.parse()
+		if err != nil {
+			if date, err = entry.ValueDate.parse(); err != nil {
+				return nil, &ParseError{Err: fmt.Errorf("entry %d: invalid booking date", i+1)}
+			}
+		}
+
+		record := &Record{
+			Date:      date,
+			Amount:    amount,
+			Reference: strings.TrimSpace(entry.AccountServicerReference),
+		}
+
+		parts := []string{}
+		if len(entry.Transactions) > 0 {
+			tx := entry.Transactions[0]
+
+			counterparty := firstNonEmpty(tx.Creditor, tx.CreditorParty)
+			if amount > 0 {
+				counterparty = firstNonEmpty(tx.Debtor, tx.DebtorParty)
+			}
+			if counterparty != "" {
+				record.Payee = counterparty
+				parts = append(parts, counterparty)
+			}
+
+			if remittance := strings.TrimSpace(strings.Join(tx.Unstructured, " ")); remittance != "" {
+				parts = append(parts, remittance)
+			} else if tx.Structured != "" {
+				parts = append(parts, strings.TrimSpace(tx.Structured))
+			}
+
+			if record.Reference == "" {
+				record.Reference = strings.TrimSpace(tx.AccountServicerReference)
+			}
+			if endToEnd := strings.TrimSpace(tx.EndToEndID); record.Reference == "" && endToEnd != "NOTPROVIDED" {
+				record.Reference = endToEnd
+			}
+		}
+		if len(parts) == 0 && strings.TrimSpace(entry.AdditionalInformation) != "" {
+			parts = append(parts, strings.TrimSpace(entry.AdditionalInformation))
+		}
+		if record.Reference == "" {
+			record.Reference = strings.TrimSpace(entry.EntryReference)
+		}
+
+		record.Description = strings.Join(strings.Fields(strings.Join(parts, " - ")), " ")
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
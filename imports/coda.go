@@ -0,0 +1,127 @@
+package imports
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// codaLineLength is the length of every CODA record.
+const codaLineLength = 128
+
+// codaField returns the trimmed field at the 1-based positions [from, to] of line.
+func codaField(line string, from int, to int) string {
+	return strings.TrimSpace(line[from-1 : to])
+}
+
+func parseCODADate(value string) (time.Time, error) {
+	return time.ParseInLocation("020106", value, time.Local)
+}
+
+// ParseCODA reads the movements from a Belgian CODA statement (version 2).
+// Only the global movements are imported, the details of globalised
+// movements are skipped so they are not counted twice.
+func ParseCODA(r io.Reader) ([]*Record, error) {
+	records := []*Record{}
+	var current *Record
+	var communication string
+	var counterparty string
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+
+		parts := []string{}
+		if counterparty != "" {
+			parts = append(parts, counterparty)
+		}
+		if communication = strings.Join(strings.Fields(communication), " "); communication != "" {
+			parts = append(parts, communication)
+		}
+		current.Payee = counterparty
+		current.Description = strings.Join(parts, " - ")
+
+		records = append(records, current)
+		current = nil
+		communication = ""
+		counterparty = ""
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if len(text) > codaLineLength {
+			return nil, &ParseError{Line: line, Err: fmt.Errorf("record longer than %d characters", codaLineLength)}
+		}
+		text += strings.Repeat(" ", codaLineLength-len(text))
+
+		switch text[0:2] {
+		case "21":
+			flush()
+
+			// Details of a globalised movement.
+			if codaField(text, 7, 10) != "0000" {
+				continue
+			}
+
+			amount, err := strconv.ParseFloat(codaField(text, 33, 47), 64)
+			if err != nil {
+				return nil, &ParseError{Line: line, Err: fmt.Errorf("invalid amount `%s`", codaField(text, 33, 47))}
+			}
+			amount /= 1000
+			if text[31] == '1' {
+				amount = -amount
+			}
+
+			date, err := parseCODADate(codaField(text, 116, 121))
+			if err != nil {
+				if date, err = parseCODADate(codaField(text, 48, 53)); err != nil {
+					return nil, &ParseError{Line: line, Err: fmt.Errorf("invalid date `%s`", codaField(text, 116, 121))}
+				}
+			}
+
+			reference := codaField(text, 11, 31)
+			if reference == "" {
+				reference = fmt.Sprintf("%s/%s/%s", codaField(text, 116, 121), codaField(text, 122, 124), codaField(text, 3, 6))
+			}
+
+			current = &Record{
+				Date:      date,
+				Amount:    amount,
+				Reference: reference,
+			}
+
+			if text[61] == '1' && strings.HasPrefix(text[62:], "101") {
+				// Structured Belgian communication: +++123/4567/89012+++.
+				digits := text[65:77]
+				communication = fmt.Sprintf("+++%s/%s/%s+++", digits[0:3], digits[3:7], digits[7:12])
+			} else {
+				communication = text[62:115]
+			}
+		case "22":
+			if current != nil {
+				communication += text[10:63]
+			}
+		case "23":
+			if current != nil {
+				counterparty = codaField(text, 48, 82)
+				communication += text[82:125]
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+
+	return records, nil
+}
@@ -0,0 +1,194 @@
+package imports
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/trtstm/budgetr/models"
+)
+
+// dateFormatTokens maps the tokens of a profile date format onto Go layouts.
+// Longer tokens come first so "yyyy" is not read as "yy" twice.
+var dateFormatTokens = []struct {
+	token  string
+	layout string
+}{
+	{"yyyy", "2006"},
+	{"yy", "06"},
+	{"mm", "01"},
+	{"dd", "02"},
+	{"m", "1"},
+	{"d", "2"},
+}
+
+// DateLayout converts a date format like "dd/mm/yyyy" into a Go time layout.
+func DateLayout(format string) string {
+	layout := ""
+	lower := strings.ToLower(format)
+
+	for i := 0; i < len(format); {
+		matched := false
+		for _, t := range dateFormatTokens {
+			if strings.HasPrefix(lower[i:], t.token) {
+				layout += t.layout
+				i += len(t.token)
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			layout += format[i : i+1]
+			i++
+		}
+	}
+
+	return layout
+}
+
+// ParseAmount parses a localized amount like "-1.234,56".
+// A trailing minus sign or surrounding parentheses also mark a negative amount.
+func ParseAmount(value string, decimalSeparator string, thousandsSeparator string) (float64, error) {
+	value = strings.Replace(value, " ", "", -1)
+	value = strings.Replace(value, "\u00a0", "", -1)
+	value = strings.Replace(value, "€", "", -1)
+	value = strings.Replace(value, "EUR", "", -1)
+	value = strings.TrimSpace(value)
+
+	negative := false
+	switch {
+	case strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")"):
+		negative = true
+		value = value[1 : len(value)-1]
+	case strings.HasSuffix(value, "-"):
+		negative = true
+		value = value[:len(value)-1]
+	}
+	value = strings.TrimSpace(value)
+
+	if thousandsSeparator != "" {
+		value = strings.Replace(value, thousandsSeparator, "", -1)
+	}
+	if decimalSeparator != "" && decimalSeparator != "." {
+		value = strings.Replace(value, decimalSeparator, ".", -1)
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount `%s`", value)
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
+
+// ValidateCSVProfile checks whether a profile can be used to parse a CSV file.
+func ValidateCSVProfile(profile *models.ImportProfile) error {
+	if utf8.RuneCountInString(profile.Delimiter) > 1 {
+		return errors.New("delimiter should be a single character")
+	}
+	if profile.SkipRows < 0 {
+		return errors.New("skip rows cant be negative")
+	}
+	if profile.DateColumn <= 0 {
+		return errors.New("date column is required")
+	}
+	if strings.TrimSpace(profile.DateFormat) == "" {
+		return errors.New("date format is required")
+	}
+	if profile.AmountColumn <= 0 {
+		return errors.New("amount column is required")
+	}
+	if profile.DescriptionColumn < 0 {
+		return errors.New("description column cant be negative")
+	}
+	if profile.PayeeColumn < 0 {
+		return errors.New("payee column cant be negative")
+	}
+	if profile.DecimalSeparator != "" && profile.DecimalSeparator == profile.ThousandsSeparator {
+		return errors.New("decimal and thousands separator should differ")
+	}
+
+	return nil
+}
+
+// ParseCSV reads all transactions from a CSV file using profile.
+func ParseCSV(r io.Reader, profile *models.ImportProfile) ([]*Record, error) {
+	if err := ValidateCSVProfile(profile); err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	if profile.Delimiter != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(profile.Delimiter)
+	}
+
+	decimalSeparator := profile.DecimalSeparator
+	if decimalSeparator == "" {
+		decimalSeparator = "."
+	}
+	layout := DateLayout(strings.TrimSpace(profile.DateFormat))
+
+	field := func(row []string, column int) (string, bool) {
+		if column <= 0 || column > len(row) {
+			return "", false
+		}
+		return strings.TrimSpace(row[column-1]), true
+	}
+
+	records := []*Record{}
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &ParseError{Line: line, Err: err}
+		}
+
+		if line == 1 && len(row) > 0 {
+			row[0] = strings.TrimPrefix(row[0], "\ufeff")
+		}
+
+		if line <= profile.SkipRows || strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		record := &Record{}
+
+		value, ok := field(row, profile.DateColumn)
+		if !ok {
+			return nil, &ParseError{Line: line, Err: fmt.Errorf("missing date column %d", profile.DateColumn)}
+		}
+		if record.Date, err = time.ParseInLocation(layout, value, time.Local); err != nil {
+			return nil, &ParseError{Line: line, Err: fmt.Errorf("invalid date `%s`", value)}
+		}
+
+		value, ok = field(row, profile.AmountColumn)
+		if !ok {
+			return nil, &ParseError{Line: line, Err: fmt.Errorf("missing amount column %d", profile.AmountColumn)}
+		}
+		if record.Amount, err = ParseAmount(value, decimalSeparator, profile.ThousandsSeparator); err != nil {
+			return nil, &ParseError{Line: line, Err: err}
+		}
+
+		record.Description, _ = field(row, profile.DescriptionColumn)
+		record.Payee, _ = field(row, profile.PayeeColumn)
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
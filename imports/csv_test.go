@@ -0,0 +1,98 @@
+package imports
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trtstm/budgetr/models"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDateLayout(t *testing.T) {
+	Convey("Converting date formats.", t, func() {
+		So(DateLayout("dd/mm/yyyy"), ShouldEqual, "02/01/2006")
+		So(DateLayout("yyyy-mm-dd"), ShouldEqual, "2006-01-02")
+		So(DateLayout("d.m.yy"), ShouldEqual, "2.1.06")
+		So(DateLayout("DD/MM/YYYY"), ShouldEqual, "02/01/2006")
+	})
+}
+
+func TestParseAmount(t *testing.T) {
+	// value, decimal separator, thousands separator -> expected
+	tests := []struct {
+		value     string
+		decimal   string
+		thousands string
+		expected  float64
+	}{
+		{"12.50", ".", "", 12.5},
+		{"-1.234,56", ",", ".", -1234.56},
+		{"1 234,56", ",", "", 1234.56},
+		{"€ 3,20-", ",", ".", -3.2},
+		{"(45.00)", ".", ",", -45},
+		{"+7,5", ",", "", 7.5},
+	}
+
+	Convey("Parsing amounts.", t, func() {
+		for _, test := range tests {
+			amount, err := ParseAmount(test.value, test.decimal, test.thousands)
+			So(err, ShouldBeNil)
+			So(amount, ShouldAlmostEqual, test.expected)
+		}
+
+		_, err := ParseAmount("abc", ".", "")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestParseCSV(t *testing.T) {
+	profile := &models.ImportProfile{
+		Delimiter:          ";",
+		SkipRows:           1,
+		DateColumn:         1,
+		DateFormat:         "dd/mm/yyyy",
+		AmountColumn:       3,
+		DecimalSeparator:   ",",
+		ThousandsSeparator: ".",
+		DescriptionColumn:  2,
+	}
+
+	Convey("Parsing a Belgian bank export.", t, func() {
+		data := "\ufeffDatum;Omschrijving;Bedrag\n" +
+			"05/03/2017;Colruyt Gent;-1.043,20\n" +
+			"\n" +
+			"06/03/2017;\"Loon; maart\";2500,00\n"
+
+		records, err := ParseCSV(strings.NewReader(data), profile)
+		So(err, ShouldBeNil)
+		So(len(records), ShouldEqual, 2)
+
+		So(records[0].Date.Equal(time.Date(2017, 3, 5, 0, 0, 0, 0, time.Local)), ShouldBeTrue)
+		So(records[0].Amount, ShouldAlmostEqual, -1043.2)
+		So(records[0].Description, ShouldEqual, "Colruyt Gent")
+
+		So(records[1].Date.Equal(time.Date(2017, 3, 6, 0, 0, 0, 0, time.Local)), ShouldBeTrue)
+		So(records[1].Amount, ShouldAlmostEqual, 2500)
+		So(records[1].Description, ShouldEqual, "Loon; maart")
+	})
+
+	Convey("Reporting the line of invalid rows.", t, func() {
+		data := "Datum;Omschrijving;Bedrag\n" +
+			"05/03/2017;Colruyt;-10,00\n" +
+			"2017-03-06;Delhaize;-5,00\n"
+
+		_, err := ParseCSV(strings.NewReader(data), profile)
+		So(err, ShouldNotBeNil)
+
+		parseErr, ok := err.(*ParseError)
+		So(ok, ShouldBeTrue)
+		So(parseErr.Line, ShouldEqual, 3)
+	})
+
+	Convey("Rejecting incomplete profiles.", t, func() {
+		_, err := ParseCSV(strings.NewReader(""), &models.ImportProfile{DateColumn: 1})
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,73 @@
+// Package imports reads transactions from bank statements.
+package imports
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Record is a single transaction read from a bank statement.
+type Record struct {
+	Date time.Time
+	// Amount is negative when money left the account.
+	Amount float64
+	// Payee is the counterparty of the transaction, if known.
+	Payee       string
+	Description string
+	// Reference is the transaction reference assigned by the bank, if known.
+	Reference string
+
+	// Account is the name of the account in the statement, if known.
+	Account string
+	// Category, Tags and Splits are only known by bookkeeping formats.
+	Category string
+	Tags     []string
+	// Splits divides the amount over several categories.
+	Splits []*Split
+}
+
+// Split is the part of a transaction that belongs to a category. Amount is positive.
+type Split struct {
+	Category string
+	Amount   float64
+}
+
+// ParseError is returned when a statement could not be parsed.
+// Line is 0 when the format is not line based.
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// ErrUnknownFormat is returned for statement formats that are not supported.
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+// parsers contains the structured statement formats that can be parsed without a profile.
+var parsers = map[string]func(io.Reader) ([]*Record, error){
+	"coda":      ParseCODA,
+	"camt053":   ParseCAMT053,
+	"ofx":       ParseOFX,
+	"ledger":    ParseLedger,
+	"hledger":   ParseLedger,
+	"beancount": ParseBeancount,
+}
+
+// ParseStatement reads all transactions from a statement in the given format.
+func ParseStatement(format string, r io.Reader) ([]*Record, error) {
+	parser, ok := parsers[format]
+	if !ok {
+		return nil, ErrUnknownFormat
+	}
+
+	return parser(r)
+}
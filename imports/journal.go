@@ -0,0 +1,431 @@
+package imports
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JournalUncategorized is the last part of the account that is used for expenditures without category.
+const JournalUncategorized = "Uncategorized"
+
+var (
+	ledgerHeaderRegexp    = regexp.MustCompile(`^(\d{4}[-/.]\d{1,2}[-/.]\d{1,2})(?:=\S+)?\s*(?:[*!]\s*)?(?:\(([^)]*)\)\s*)?(.*)$`)
+	ledgerTagsRegexp      = regexp.MustCompile(`:((?:[^:\s]+:)+)`)
+	hledgerTagRegexp      = regexp.MustCompile(`(?:^|[\s,])([^\s:,]+):`)
+	beancountHeaderRegexp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s+(?:txn|\*|!)(.*)$`)
+	beancountStringRegexp = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+	beancountTagRegexp    = regexp.MustCompile(`(?:^|\s)#([A-Za-z0-9\-_/.]+)`)
+	beancountMetaRegexp   = regexp.MustCompile(`^([a-z][A-Za-z0-9\-_]*):\s*(.*)$`)
+	journalAmountRegexp   = regexp.MustCompile(`-?\s*\d[\d,]*(?:\.\d+)?`)
+)
+
+// journalPosting is a line of a journal transaction that moves an amount to or from an account.
+type journalPosting struct {
+	Account   string
+	Amount    float64
+	HasAmount bool
+}
+
+// journalTransaction is a transaction read from a ledger, hledger or beancount file.
+type journalTransaction struct {
+	Line        int
+	Date        time.Time
+	Reference   string
+	Payee       string
+	Description string
+	Tags        []string
+	Postings    []*journalPosting
+}
+
+// parseJournalAmount parses amounts like `43.20 EUR`, `€-43.20`, `-€1,043.20` or `43.20 EUR @ 1.1 USD`.
+func parseJournalAmount(value string) (float64, error) {
+	if i := strings.IndexAny(value, "@{"); i >= 0 {
+		value = value[:i]
+	}
+
+	number := journalAmountRegexp.FindString(value)
+	if number == "" {
+		return 0, fmt.Errorf("invalid amount `%s`", strings.TrimSpace(value))
+	}
+
+	negative := strings.Contains(strings.TrimSpace(value[:strings.Index(value, number)]), "-")
+	number = strings.NewReplacer(" ", "", ",", "").Replace(number)
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount `%s`", strings.TrimSpace(value))
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
+
+// parsePosting parses `account  amount`. The account and amount are separated
+// by at least two spaces or a tab, the amount can be left out.
+func parsePosting(line string) (*journalPosting, error) {
+	line = strings.TrimSpace(strings.Replace(line, "\t", "  ", -1))
+	posting := &journalPosting{Account: line}
+
+	if i := strings.Index(line, "  "); i >= 0 {
+		posting.Account = line[:i]
+		amount, err := parseJournalAmount(line[i:])
+		if err != nil {
+			return nil, err
+		}
+		posting.Amount = amount
+		posting.HasAmount = true
+	}
+
+	return posting, nil
+}
+
+// journalRoot returns the kind of account, the first part of its name.
+func journalRoot(account string) string {
+	return strings.ToLower(strings.SplitN(account, ":", 2)[0])
+}
+
+// journalLeaf returns the last part of the name of an account.
+func journalLeaf(account string) string {
+	parts := strings.Split(account, ":")
+	return parts[len(parts)-1]
+}
+
+// record converts t to a Record. Transactions between two asset accounts or
+// involving other kinds of accounts, like equity, have no Record and ok is false.
+func (t *journalTransaction) record() (record *Record, ok bool, err error) {
+	// One posting can leave out its amount, it balances the transaction.
+	var elided *journalPosting
+	balance := 0.0
+	for _, posting := range t.Postings {
+		if !posting.HasAmount {
+			if elided != nil {
+				return nil, false, errors.New("more than one posting without amount")
+			}
+			elided = posting
+		}
+		balance += posting.Amount
+	}
+	if elided != nil {
+		elided.Amount = -balance
+	}
+
+	record = &Record{
+		Date:        t.Date,
+		Payee:       t.Payee,
+		Description: t.Description,
+		Reference:   t.Reference,
+		Tags:        t.Tags,
+	}
+
+	categories := []*journalPosting{}
+	total := 0.0
+	for _, posting := range t.Postings {
+		switch journalRoot(posting.Account) {
+		case "assets", "liabilities":
+			if record.Account == "" {
+				record.Account = journalLeaf(posting.Account)
+			}
+		case "expenses", "income", "revenue", "revenues":
+			categories = append(categories, posting)
+			total += posting.Amount
+		default:
+			return nil, false, nil
+		}
+	}
+	if record.Account == "" || len(categories) == 0 {
+		return nil, false, nil
+	}
+
+	// Spending is positive in an expense account, money leaves the asset account.
+	record.Amount = -total
+
+	amounts := map[string]float64{}
+	names := []string{}
+	for _, posting := range categories {
+		category := journalLeaf(posting.Account)
+		if category == JournalUncategorized || !strings.Contains(posting.Account, ":") {
+			category = ""
+		}
+		if _, ok := amounts[category]; !ok {
+			names = append(names, category)
+		}
+		amounts[category] += posting.Amount
+	}
+
+	if len(names) == 1 {
+		record.Category = names[0]
+		return record, true, nil
+	}
+
+	for _, name := range names {
+		amount := amounts[name]
+		if total < 0 {
+			amount = -amount
+		}
+		if amount < 0 {
+			// A split can not go against the direction of the transaction.
+			return nil, false, nil
+		}
+		record.Splits = append(record.Splits, &Split{Category: name, Amount: amount})
+	}
+
+	return record, true, nil
+}
+
+// journalParser reads the transactions of a line based journal file.
+type journalParser struct {
+	records  []*Record
+	current  *journalTransaction
+	parseTop func(line string, number int) (*journalTransaction, error)
+	parseSub func(t *journalTransaction, line string) error
+}
+
+// finish converts the current transaction to a record.
+func (p *journalParser) finish() error {
+	if p.current == nil {
+		return nil
+	}
+
+	record, ok, err := p.current.record()
+	if err != nil {
+		return &ParseError{Line: p.current.Line, Err: err}
+	}
+	if ok {
+		p.records = append(p.records, record)
+	}
+	p.current = nil
+
+	return nil
+}
+
+func (p *journalParser) parse(r io.Reader) ([]*Record, error) {
+	p.records = []*Record{}
+
+	scanner := bufio.NewScanner(r)
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if line == "" {
+			if err := p.finish(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			// Indented lines of directives other than transactions are ignored.
+			if p.current != nil {
+				if err := p.parseSub(p.current, strings.TrimSpace(line)); err != nil {
+					return nil, &ParseError{Line: number, Err: err}
+				}
+			}
+			continue
+		}
+
+		if err := p.finish(); err != nil {
+			return nil, err
+		}
+
+		transaction, err := p.parseTop(line, number)
+		if err != nil {
+			return nil, &ParseError{Line: number, Err: err}
+		}
+		p.current = transaction
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := p.finish(); err != nil {
+		return nil, err
+	}
+
+	return p.records, nil
+}
+
+// parseJournalDate parses dates like 2017-03-05, 2017/03/05 or 2017.3.5.
+func parseJournalDate(value string) (time.Time, error) {
+	value = strings.NewReplacer("/", "-", ".", "-").Replace(value)
+	return time.ParseInLocation("2006-1-2", value, time.Local)
+}
+
+// splitComment splits a line in its content and the comment after `;`.
+func splitComment(line string) (string, string) {
+	if i := strings.Index(line, ";"); i >= 0 {
+		return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+	}
+
+	return line, ""
+}
+
+// ledgerTags returns the tags in a ledger (`:kids:holiday:`) or hledger (`kids:, holiday:`) comment.
+func ledgerTags(comment string) []string {
+	tags := []string{}
+	for _, match := range ledgerTagsRegexp.FindAllStringSubmatch(comment, -1) {
+		for _, tag := range strings.Split(strings.Trim(match[1], ":"), ":") {
+			tags = append(tags, tag)
+		}
+	}
+	for _, match := range hledgerTagRegexp.FindAllStringSubmatch(ledgerTagsRegexp.ReplaceAllString(comment, ""), -1) {
+		tags = append(tags, match[1])
+	}
+
+	return tags
+}
+
+// ParseLedger reads the transactions from a ledger or hledger journal. The
+// payee and description are separated by ` | `. Expense and income accounts
+// become the category, the account is the last part of the asset account.
+// Transactions between asset accounts are skipped.
+func ParseLedger(r io.Reader) ([]*Record, error) {
+	p := &journalParser{
+		parseTop: func(line string, number int) (*journalTransaction, error) {
+			match := ledgerHeaderRegexp.FindStringSubmatch(line)
+			if match == nil {
+				// Comments and other directives.
+				return nil, nil
+			}
+
+			date, err := parseJournalDate(match[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid date `%s`", match[1])
+			}
+
+			text, comment := splitComment(match[3])
+			t := &journalTransaction{Line: number, Date: date, Reference: strings.TrimSpace(match[2]), Tags: ledgerTags(comment)}
+			if i := strings.Index(text, "|"); i >= 0 {
+				t.Payee = strings.TrimSpace(text[:i])
+				t.Description = strings.TrimSpace(text[i+1:])
+			} else {
+				t.Description = text
+			}
+
+			return t, nil
+		},
+		parseSub: func(t *journalTransaction, line string) error {
+			content, comment := splitComment(line)
+			if comment != "" {
+				t.Tags = append(t.Tags, ledgerTags(comment)...)
+			}
+			if content == "" {
+				return nil
+			}
+
+			posting, err := parsePosting(content)
+			if err != nil {
+				return err
+			}
+			t.Postings = append(t.Postings, posting)
+			return nil
+		},
+	}
+
+	return p.parse(r)
+}
+
+// beancountContent strips the comment after `;` from a beancount line,
+// ignoring semicolons inside strings.
+func beancountContent(line string) string {
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				return strings.TrimSpace(line[:i])
+			}
+		}
+	}
+
+	return strings.TrimSpace(line)
+}
+
+// beancountUnquote returns the content of a beancount string.
+func beancountUnquote(value string) string {
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value)
+}
+
+// ParseBeancount reads the transactions from a beancount file. The reference
+// is read from the `reference` metadata. Expense and income accounts become
+// the category, the account is the last part of the asset account.
+// Transactions between asset accounts are skipped.
+func ParseBeancount(r io.Reader) ([]*Record, error) {
+	p := &journalParser{
+		parseTop: func(line string, number int) (*journalTransaction, error) {
+			match := beancountHeaderRegexp.FindStringSubmatch(line)
+			if match == nil {
+				// Options, open directives, balances and comments.
+				return nil, nil
+			}
+
+			date, err := parseJournalDate(match[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid date `%s`", match[1])
+			}
+
+			t := &journalTransaction{Line: number, Date: date, Tags: []string{}}
+
+			rest := beancountContent(match[2])
+			strs := beancountStringRegexp.FindAllStringSubmatch(rest, -1)
+			switch len(strs) {
+			case 1:
+				t.Description = beancountUnquote(strs[0][1])
+			case 2:
+				t.Payee = beancountUnquote(strs[0][1])
+				t.Description = beancountUnquote(strs[1][1])
+			}
+
+			for _, tag := range beancountTagRegexp.FindAllStringSubmatch(beancountStringRegexp.ReplaceAllString(rest, ""), -1) {
+				t.Tags = append(t.Tags, tag[1])
+			}
+
+			return t, nil
+		},
+		parseSub: func(t *journalTransaction, line string) error {
+			if strings.HasPrefix(line, ";") {
+				return nil
+			}
+
+			if match := beancountMetaRegexp.FindStringSubmatch(line); match != nil {
+				if match[1] == "reference" {
+					t.Reference = beancountUnquote(strings.Trim(beancountContent(match[2]), `"`))
+				}
+				return nil
+			}
+
+			// Postings can be flagged like transactions.
+			content := beancountContent(strings.TrimLeft(line, "*! "))
+			fields := strings.Fields(content)
+			if len(fields) == 0 {
+				return nil
+			}
+
+			posting := &journalPosting{Account: fields[0]}
+			if len(fields) > 1 {
+				amount, err := parseJournalAmount(strings.Join(fields[1:], " "))
+				if err != nil {
+					return err
+				}
+				posting.Amount = amount
+				posting.HasAmount = true
+			}
+
+			t.Postings = append(t.Postings, posting)
+			return nil
+		},
+	}
+
+	return p.parse(r)
+}
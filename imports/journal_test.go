@@ -0,0 +1,126 @@
+package imports
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseJournalAmount(t *testing.T) {
+	tests := map[string]float64{
+		"43.20 EUR":            43.2,
+		"-43.20 EUR":           -43.2,
+		"€-43.20":              -43.2,
+		"-€1,043.20":           -1043.2,
+		"$ 12":                 12,
+		"10 EUR @ 1.1 USD":     10,
+		"-5.5 EUR {1.2 USD}":   -5.5,
+		"43.20 EUR ; a remark": 43.2,
+	}
+
+	Convey("Parsing journal amounts.", t, func() {
+		for value, expected := range tests {
+			amount, err := parseJournalAmount(value)
+			So(err, ShouldBeNil)
+			So(amount, ShouldAlmostEqual, expected)
+		}
+
+		_, err := parseJournalAmount("EUR")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestParseLedger(t *testing.T) {
+	data := `; A ledger journal
+account Assets:Bank
+
+2017/03/05 * (REF-1) Colruyt | Weekly groceries  ; :kids:holiday:
+    Expenses:Food:Groceries          43.20 EUR
+    Assets:Bank
+
+2017-03-06 Employer
+    ; salary:
+    Assets:Bank                     2500 EUR
+    Income:Salary
+
+2017-03-07 Transfer
+    Assets:Savings                   100 EUR
+    Assets:Bank                     -100 EUR
+
+2017-03-08 Hardware store
+    Expenses:House                   30 EUR
+    Expenses:Uncategorized           10 EUR
+    Liabilities:Credit Card
+`
+
+	Convey("Parsing a ledger journal.", t, func() {
+		records, err := ParseStatement("ledger", strings.NewReader(data))
+		So(err, ShouldBeNil)
+		So(len(records), ShouldEqual, 3)
+
+		So(records[0].Date.Equal(time.Date(2017, 3, 5, 0, 0, 0, 0, time.Local)), ShouldBeTrue)
+		So(records[0].Amount, ShouldAlmostEqual, -43.2)
+		So(records[0].Reference, ShouldEqual, "REF-1")
+		So(records[0].Payee, ShouldEqual, "Colruyt")
+		So(records[0].Description, ShouldEqual, "Weekly groceries")
+		So(records[0].Category, ShouldEqual, "Groceries")
+		So(records[0].Account, ShouldEqual, "Bank")
+		So(records[0].Tags, ShouldResemble, []string{"kids", "holiday"})
+
+		So(records[1].Amount, ShouldAlmostEqual, 2500)
+		So(records[1].Category, ShouldEqual, "Salary")
+		So(records[1].Tags, ShouldResemble, []string{"salary"})
+
+		So(records[2].Amount, ShouldAlmostEqual, -40)
+		So(records[2].Account, ShouldEqual, "Credit Card")
+		So(len(records[2].Splits), ShouldEqual, 2)
+		So(*records[2].Splits[0], ShouldResemble, Split{Category: "House", Amount: 30})
+		So(*records[2].Splits[1], ShouldResemble, Split{Category: "", Amount: 10})
+	})
+
+	Convey("Transactions that do not balance are rejected.", t, func() {
+		_, err := ParseLedger(strings.NewReader("2017-03-05 Shop\n    Expenses:Food\n    Assets:Bank\n"))
+		So(err, ShouldNotBeNil)
+		So(err.(*ParseError).Line, ShouldEqual, 1)
+	})
+}
+
+func TestParseBeancount(t *testing.T) {
+	data := `option "operating_currency" "EUR"
+
+2017-01-01 open Assets:Bank
+2017-01-01 open Expenses:Food
+
+2017-03-05 * "Colruyt" "Weekly \"big\" groceries; fruit" #kids ; checked
+  reference: "REF-1"
+  * ; checked at the till
+  Expenses:Food  43.20 EUR
+  !
+  Assets:Bank  -43.20 EUR
+
+2017-03-06 txn "Salary"
+  Assets:Bank  2500.00 EUR
+  Income:Salary
+
+2017-03-31 balance Assets:Bank  2456.80 EUR
+`
+
+	Convey("Parsing a beancount file.", t, func() {
+		records, err := ParseStatement("beancount", strings.NewReader(data))
+		So(err, ShouldBeNil)
+		So(len(records), ShouldEqual, 2)
+
+		So(records[0].Amount, ShouldAlmostEqual, -43.2)
+		So(records[0].Payee, ShouldEqual, "Colruyt")
+		So(records[0].Description, ShouldEqual, `Weekly "big" groceries; fruit`)
+		So(records[0].Reference, ShouldEqual, "REF-1")
+		So(records[0].Category, ShouldEqual, "Food")
+		So(records[0].Tags, ShouldResemble, []string{"kids"})
+
+		So(records[1].Amount, ShouldAlmostEqual, 2500)
+		So(records[1].Payee, ShouldEqual, "")
+		So(records[1].Description, ShouldEqual, "Salary")
+	})
+}
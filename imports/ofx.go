@@ -0,0 +1,83 @@
+package imports
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ofxTransactionRegexp = regexp.MustCompile(`(?is)<STMTTRN>(.*?)</STMTTRN>`)
+	ofxFieldRegexp       = regexp.MustCompile(`<([A-Za-z0-9.]+)>([^<]*)`)
+	ofxEntities          = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'")
+)
+
+// parseOFXDate parses dates like 20170305, 20170305120000 or 20170305120000.000[-5:EST].
+// The timezone is ignored, the date is interpreted in local time.
+func parseOFXDate(value string) (time.Time, error) {
+	if i := strings.IndexAny(value, ".["); i >= 0 {
+		value = value[:i]
+	}
+
+	switch len(value) {
+	case 8:
+		return time.ParseInLocation("20060102", value, time.Local)
+	case 12:
+		return time.ParseInLocation("200601021504", value, time.Local)
+	case 14:
+		return time.ParseInLocation("20060102150405", value, time.Local)
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date `%s`", value)
+}
+
+// ParseOFX reads the transactions from an OFX statement. Both the SGML based
+// version 1 files, where closing tags are optional, and XML version 2 files are supported.
+func ParseOFX(r io.Reader) ([]*Record, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*Record{}
+	for i, match := range ofxTransactionRegexp.FindAllStringSubmatch(string(data), -1) {
+		fields := map[string]string{}
+		for _, field := range ofxFieldRegexp.FindAllStringSubmatch(match[1], -1) {
+			name := strings.ToUpper(field[1])
+			if _, ok := fields[name]; !ok {
+				fields[name] = strings.TrimSpace(ofxEntities.Replace(field[2]))
+			}
+		}
+
+		date, err := parseOFXDate(fields["DTPOSTED"])
+		if err != nil {
+			return nil, &ParseError{Err: fmt.Errorf("transaction %d: %v", i+1, err)}
+		}
+
+		amount, err := strconv.ParseFloat(strings.Replace(fields["TRNAMT"], ",", ".", -1), 64)
+		if err != nil {
+			return nil, &ParseError{Err: fmt.Errorf("transaction %d: invalid amount `%s`", i+1, fields["TRNAMT"])}
+		}
+
+		parts := []string{}
+		for _, name := range []string{"NAME", "MEMO"} {
+			if fields[name] != "" {
+				parts = append(parts, fields[name])
+			}
+		}
+
+		records = append(records, &Record{
+			Date:        date,
+			Amount:      amount,
+			Payee:       fields["NAME"],
+			Description: strings.Join(parts, " - "),
+			Reference:   fields["FITID"],
+		})
+	}
+
+	return records, nil
+}
@@ -0,0 +1,143 @@
+package imports
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// codaLine builds a CODA record by placing values at their 1-based start positions.
+func codaLine(fields map[int]string) string {
+	line := []byte(strings.Repeat(" ", codaLineLength))
+	for pos, value := range fields {
+		copy(line[pos-1:], value)
+	}
+	return string(line)
+}
+
+func TestParseCODA(t *testing.T) {
+	data := strings.Join([]string{
+		codaLine(map[int]string{1: "0000005031772505"}),
+		codaLine(map[int]string{1: "12001"}),
+		codaLine(map[int]string{1: "21", 3: "0001", 7: "0000", 11: "EBA123456789", 32: "1", 33: "000000000043200", 48: "040317", 54: "00501000", 62: "0", 63: "Colruyt Gent", 116: "050317", 126: "0", 128: "1"}),
+		codaLine(map[int]string{1: "23", 3: "0001", 7: "0000", 11: "BE68539007547034", 48: "COLRUYT NV"}),
+		codaLine(map[int]string{1: "21", 3: "0002", 7: "0000", 11: "EBA987654321", 32: "0", 33: "000000002500000", 48: "060317", 62: "1", 63: "101012345678901", 116: "060317"}),
+		codaLine(map[int]string{1: "21", 3: "0003", 7: "0001", 11: "EBA987654321", 32: "0", 33: "000000001000000", 116: "060317"}),
+		codaLine(map[int]string{1: "8"}),
+		codaLine(map[int]string{1: "9"}),
+	}, "\r\n")
+
+	Convey("Parsing a CODA statement.", t, func() {
+		records, err := ParseCODA(strings.NewReader(data))
+		So(err, ShouldBeNil)
+		So(len(records), ShouldEqual, 2)
+
+		So(records[0].Date.Equal(time.Date(2017, 3, 5, 0, 0, 0, 0, time.Local)), ShouldBeTrue)
+		So(records[0].Amount, ShouldAlmostEqual, -43.2)
+		So(records[0].Reference, ShouldEqual, "EBA123456789")
+		So(records[0].Payee, ShouldEqual, "COLRUYT NV")
+		So(records[0].Description, ShouldEqual, "COLRUYT NV - Colruyt Gent")
+
+		So(records[1].Amount, ShouldAlmostEqual, 2500)
+		So(records[1].Description, ShouldEqual, "+++012/3456/78901+++")
+	})
+}
+
+func TestParseCAMT053(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Ntry>
+        <Amt Ccy="EUR">43.20</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2017-03-05</Dt></BookgDt>
+        <AcctSvcrRef>REF-001</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <RltdPties><Cdtr><Nm>Colruyt NV</Nm></Cdtr></RltdPties>
+          <RmtInf><Ustrd>Colruyt Gent</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">2500.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><DtTm>2017-03-06T10:00:00</DtTm></BookgDt>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>SALARY-03</EndToEndId></Refs>
+          <RltdPties><Dbtr><Nm>Employer</Nm></Dbtr></RltdPties>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">10.00</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>PDNG</Sts>
+        <BookgDt><Dt>2017-03-07</Dt></BookgDt>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+	Convey("Parsing a CAMT.053 statement.", t, func() {
+		records, err := ParseCAMT053(strings.NewReader(data))
+		So(err, ShouldBeNil)
+		So(len(records), ShouldEqual, 2)
+
+		So(records[0].Date.Equal(time.Date(2017, 3, 5, 0, 0, 0, 0, time.Local)), ShouldBeTrue)
+		So(records[0].Amount, ShouldAlmostEqual, -43.2)
+		So(records[0].Reference, ShouldEqual, "REF-001")
+		So(records[0].Payee, ShouldEqual, "Colruyt NV")
+		So(records[0].Description, ShouldEqual, "Colruyt NV - Colruyt Gent")
+
+		So(records[1].Amount, ShouldAlmostEqual, 2500)
+		So(records[1].Reference, ShouldEqual, "SALARY-03")
+		So(records[1].Description, ShouldEqual, "Employer")
+	})
+}
+
+func TestParseOFX(t *testing.T) {
+	data := `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS><BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20170305120000.000[+1:CET]
+<TRNAMT>-43.20
+<FITID>20170305-1
+<NAME>Colruyt &amp; Co
+<MEMO>Gent
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20170306
+<TRNAMT>2500,00
+<FITID>20170306-1
+<NAME>Employer
+</STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>`
+
+	Convey("Parsing an OFX statement.", t, func() {
+		records, err := ParseStatement("ofx", strings.NewReader(data))
+		So(err, ShouldBeNil)
+		So(len(records), ShouldEqual, 2)
+
+		So(records[0].Date.Equal(time.Date(2017, 3, 5, 12, 0, 0, 0, time.Local)), ShouldBeTrue)
+		So(records[0].Amount, ShouldAlmostEqual, -43.2)
+		So(records[0].Reference, ShouldEqual, "20170305-1")
+		So(records[0].Description, ShouldEqual, "Colruyt & Co - Gent")
+
+		So(records[1].Amount, ShouldAlmostEqual, 2500)
+	})
+
+	Convey("Unknown formats are rejected.", t, func() {
+		_, err := ParseStatement("qif", strings.NewReader(data))
+		So(err, ShouldEqual, ErrUnknownFormat)
+	})
+}
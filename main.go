@@ -50,9 +50,17 @@ func main() {
 		log.Fatalf("Failed to initialize database schema: %v", err)
 	}
 
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+
 	quit := make(chan struct{})
 	handleInterrupt(quit)
 
+	go runScheduler()
 	go startAPI()
 
 	<-quit
@@ -0,0 +1,36 @@
+package models
+
+import "github.com/jinzhu/gorm"
+
+// AccountType is the kind of account money is kept in.
+type AccountType string
+
+// Supported account types.
+const (
+	AccountChecking   AccountType = "checking"
+	AccountSavings    AccountType = "savings"
+	AccountCash       AccountType = "cash"
+	AccountCreditCard AccountType = "credit_card"
+)
+
+// DefaultAccountName is the name of the account that is created when none exist.
+const DefaultAccountName = "Default"
+
+// Valid returns whether t is one of the supported account types.
+func (t AccountType) Valid() bool {
+	switch t {
+	case AccountChecking, AccountSavings, AccountCash, AccountCreditCard:
+		return true
+	}
+
+	return false
+}
+
+// Account represents a place where money is kept, like a bank account or a wallet.
+type Account struct {
+	gorm.Model
+
+	Name           string      `gorm:"not null;unique"`
+	Type           AccountType `gorm:"not null;default:'checking'"`
+	OpeningBalance float64     `gorm:"not null"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// BudgetPeriodFormat is the layout of Budget.Period. A budget always covers
+// a single calendar month.
+const BudgetPeriodFormat = "2006-01"
+
+// Budget represents the spending limit of a category for one month.
+type Budget struct {
+	gorm.Model
+
+	Category   *Category `gorm:"ForeignKey:CategoryID"`
+	CategoryID uint      `gorm:"not null;unique_index:idx_budget_category_period"`
+
+	Period string  `gorm:"not null;unique_index:idx_budget_category_period"`
+	Amount float64 `gorm:"not null"`
+}
+
+// ParseBudgetPeriod returns the [start, end) range covered by period.
+func ParseBudgetPeriod(period string) (start time.Time, end time.Time, err error) {
+	start, err = time.ParseInLocation(BudgetPeriodFormat, period, time.Local)
+	if err != nil {
+		return
+	}
+
+	end = start.AddDate(0, 1, 0)
+	return
+}
@@ -7,4 +7,14 @@ type Category struct {
 	gorm.Model
 
 	Name string `gorm:"not null;unique"`
+	// ParentID is the category this one belongs to, 0 for top level categories.
+	ParentID uint `gorm:"index"`
+
+	// Color is a hex color like "#ff8800".
+	Color string
+	Icon  string
+	// SortOrder orders the categories in lists, lower first.
+	SortOrder int `gorm:"not null;default:0"`
+	// Archived categories are hidden from pickers but still show in statistics.
+	Archived bool `gorm:"not null;default:false"`
 }
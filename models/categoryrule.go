@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// CategoryRule assigns a category to new expenditures without one.
+// A rule matches when all of its conditions match, empty conditions match everything.
+type CategoryRule struct {
+	gorm.Model
+
+	Name string
+	// Priority orders the rules, the matching rule with the highest priority wins.
+	Priority int `gorm:"not null;default:0;index"`
+
+	// Contains is matched case insensitively against the description.
+	Contains string
+	// Pattern is a regular expression matched against the description.
+	Pattern string
+	// MinAmount and MaxAmount bound the amount, 0 means unbounded.
+	MinAmount float64
+	MaxAmount float64
+	// Direction restricts the rule to expenses or income.
+	Direction Direction
+	// AccountID restricts the rule to one account.
+	AccountID uint
+	// Weekdays is a bitmask of time.Weekday values, 0 means every day.
+	Weekdays uint
+
+	Category   *Category `gorm:"ForeignKey:CategoryID"`
+	CategoryID uint      `gorm:"not null"`
+}
+
+// HasWeekday returns whether the rule applies on day.
+func (r *CategoryRule) HasWeekday(day time.Weekday) bool {
+	return r.Weekdays == 0 || r.Weekdays&(1<<uint(day)) != 0
+}
+
+// WeekdayList returns the days the rule is restricted to.
+func (r *CategoryRule) WeekdayList() []time.Weekday {
+	days := []time.Weekday{}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if r.Weekdays&(1<<uint(day)) != 0 {
+			days = append(days, day)
+		}
+	}
+
+	return days
+}
+
+// SetWeekdays restricts the rule to days. No days means every day.
+func (r *CategoryRule) SetWeekdays(days ...time.Weekday) {
+	r.Weekdays = 0
+	for _, day := range days {
+		r.Weekdays |= 1 << uint(day)
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import "github.com/jinzhu/gorm"
+
+// Resolutions of a duplicate candidate.
+const (
+	DuplicateKept   = "kept"
+	DuplicateMerged = "merged"
+)
+
+// DuplicateCandidate links two expenditures that are likely the same.
+// Duplicate is always the one that was added last.
+type DuplicateCandidate struct {
+	gorm.Model
+
+	Expenditure   *Expenditure `gorm:"ForeignKey:ExpenditureID"`
+	ExpenditureID uint         `gorm:"not null;index"`
+
+	Duplicate   *Expenditure `gorm:"ForeignKey:DuplicateID"`
+	DuplicateID uint         `gorm:"not null;index"`
+
+	Score  float64 `gorm:"not null"`
+	Reason string  `gorm:"not null"`
+
+	// Resolution is empty as long as the candidate has not been reviewed.
+	Resolution string
+}
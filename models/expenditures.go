@@ -6,13 +6,43 @@ import (
 	"github.com/jinzhu/gorm"
 )
 
+// Direction tells whether money leaves or enters the household.
+type Direction string
+
+const (
+	// DirectionExpense is money that is spent.
+	DirectionExpense Direction = "expense"
+	// DirectionIncome is money that is received.
+	DirectionIncome Direction = "income"
+)
+
 // Expenditure represents a single expenditure.
+// Income is stored in the same table with DirectionIncome.
 type Expenditure struct {
 	gorm.Model
 
-	Amount float64   `gorm:"not null"`
-	Date   time.Time `gorm:"not null"`
+	Amount    float64   `gorm:"not null"`
+	Date      time.Time `gorm:"not null"`
+	Direction Direction `gorm:"not null;default:'expense';index"`
+
+	// Payee is who was paid, or who paid for income.
+	Payee       string `gorm:"index"`
+	Description string
+	Notes       string
+	// Reference is the transaction reference of the bank for imported expenditures.
+	Reference string `gorm:"index"`
+	// RecurringID is the template the expenditure was created from, if any.
+	RecurringID uint `gorm:"default:0;index"`
 
 	Category   *Category `gorm:"ForeignKey:CategoryID"`
 	CategoryID uint
+
+	Account   *Account `gorm:"ForeignKey:AccountID"`
+	AccountID uint     `gorm:"index"`
+
+	Tags []*Tag `gorm:"many2many:expenditure_tags"`
+
+	// Splits divide the amount over several categories. When present,
+	// they are used in the statistics instead of Category.
+	Splits []*ExpenditureSplit `gorm:"ForeignKey:ExpenditureID"`
 }
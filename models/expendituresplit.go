@@ -0,0 +1,16 @@
+package models
+
+import "github.com/jinzhu/gorm"
+
+// ExpenditureSplit attributes part of an expenditure to a category.
+// The splits of an expenditure always add up to its amount.
+type ExpenditureSplit struct {
+	gorm.Model
+
+	ExpenditureID uint `gorm:"not null;index"`
+
+	Category   *Category `gorm:"ForeignKey:CategoryID"`
+	CategoryID uint
+
+	Amount float64 `gorm:"not null"`
+}
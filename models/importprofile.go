@@ -0,0 +1,27 @@
+package models
+
+import "github.com/jinzhu/gorm"
+
+// ImportProfile describes how the columns of a bank CSV export map onto expenditures.
+// Columns are numbered from 1, a column of 0 means the column is not present.
+type ImportProfile struct {
+	gorm.Model
+
+	Name string `gorm:"not null;unique"`
+
+	// Delimiter separates the fields of a row. Defaults to ",".
+	Delimiter string `gorm:"not null;default:','"`
+	// SkipRows is the number of header rows before the first transaction.
+	SkipRows int `gorm:"not null"`
+
+	DateColumn int `gorm:"not null"`
+	// DateFormat uses dd, mm, yy and yyyy, e.g. "dd/mm/yyyy".
+	DateFormat string `gorm:"not null"`
+
+	AmountColumn       int    `gorm:"not null"`
+	DecimalSeparator   string `gorm:"not null;default:'.'"`
+	ThousandsSeparator string
+
+	DescriptionColumn int
+	PayeeColumn       int
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// RecurrenceUnit is the unit of the interval between two occurrences.
+type RecurrenceUnit string
+
+// Supported recurrence units.
+const (
+	RecurrenceDaily   RecurrenceUnit = "day"
+	RecurrenceWeekly  RecurrenceUnit = "week"
+	RecurrenceMonthly RecurrenceUnit = "month"
+	RecurrenceYearly  RecurrenceUnit = "year"
+)
+
+// Valid returns whether u is one of the supported units.
+func (u RecurrenceUnit) Valid() bool {
+	switch u {
+	case RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly, RecurrenceYearly:
+		return true
+	}
+
+	return false
+}
+
+// Recurring is a template for an expenditure that comes back on a schedule,
+// e.g. every month on the 5th or every 2 weeks. The first occurrence is on
+// StartDate, the next ones every Interval units after it.
+type Recurring struct {
+	gorm.Model
+
+	Name        string
+	Amount      float64   `gorm:"not null"`
+	Direction   Direction `gorm:"not null;default:'expense'"`
+	Payee       string
+	Description string
+
+	Category   *Category `gorm:"ForeignKey:CategoryID"`
+	CategoryID uint
+	AccountID  uint
+
+	Unit     RecurrenceUnit `gorm:"not null"`
+	Interval int            `gorm:"not null;default:1"`
+	// StartDate is the first occurrence.
+	StartDate time.Time `gorm:"not null"`
+	// EndDate is the last day occurrences can fall on, zero when there is no end.
+	EndDate time.Time
+
+	// Count is the number of occurrences that were created.
+	Count int `gorm:"not null"`
+	// NextDate is the date of the next occurrence that has to be created.
+	NextDate time.Time `gorm:"not null;index"`
+	Paused   bool      `gorm:"not null;default:false"`
+}
+
+// Occurrence returns the date of the n-th occurrence, starting at 0.
+// Monthly and yearly schedules that start on a day that does not exist in
+// every month, like the 31st, fall on the last day of the shorter months.
+func (r *Recurring) Occurrence(n int) time.Time {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	steps := n * interval
+
+	switch r.Unit {
+	case RecurrenceDaily:
+		return r.StartDate.AddDate(0, 0, steps)
+	case RecurrenceWeekly:
+		return r.StartDate.AddDate(0, 0, 7*steps)
+	case RecurrenceYearly:
+		steps *= 12
+	}
+
+	year, month, day := r.StartDate.Date()
+	// Day 0 of the next month is the last day of this month.
+	lastDay := time.Date(year, month+time.Month(steps)+1, 0, 0, 0, 0, 0, r.StartDate.Location()).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	hour, min, sec := r.StartDate.Clock()
+	return time.Date(year, month+time.Month(steps), day, hour, min, sec, r.StartDate.Nanosecond(), r.StartDate.Location())
+}
+
+// Ended returns whether date is past the end of the schedule.
+func (r *Recurring) Ended(date time.Time) bool {
+	return !r.EndDate.IsZero() && date.After(r.EndDate)
+}
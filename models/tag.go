@@ -0,0 +1,10 @@
+package models
+
+import "github.com/jinzhu/gorm"
+
+// Tag groups expenditures across categories, e.g. all expenses of a holiday.
+type Tag struct {
+	gorm.Model
+
+	Name string `gorm:"not null;unique"`
+}
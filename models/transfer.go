@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Transfer represents money moved from one account to another.
+// Transfers are not counted as spending or income.
+type Transfer struct {
+	gorm.Model
+
+	Amount float64   `gorm:"not null"`
+	Date   time.Time `gorm:"not null"`
+
+	FromAccount   *Account `gorm:"ForeignKey:FromAccountID"`
+	FromAccountID uint     `gorm:"not null;index"`
+
+	ToAccount   *Account `gorm:"ForeignKey:ToAccountID"`
+	ToAccountID uint     `gorm:"not null;index"`
+}
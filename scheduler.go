@@ -0,0 +1,31 @@
+package main
+
+import (
+	"time"
+
+	"github.com/trtstm/budgetr/controllers"
+	"github.com/trtstm/budgetr/log"
+)
+
+// schedulerInterval is how often the scheduler looks for recurring expenditures that are due.
+const schedulerInterval = time.Hour
+
+// runScheduler creates the recurring expenditures that are due. It runs right
+// away to catch up on what was missed while the server was down, and then
+// every schedulerInterval.
+func runScheduler() {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		created, err := controllers.MaterializeRecurring(time.Now())
+		if err != nil {
+			log.Errorf("Scheduler: %v", err)
+		}
+		if created > 0 {
+			log.Infof("Scheduler: created %d recurring expenditures.", created)
+		}
+
+		<-ticker.C
+	}
+}